@@ -0,0 +1,199 @@
+// Package admin wraps the Cognito admin operations needed to bootstrap users for tests. The operations are authorized
+// with IAM credentials rather than user tokens.
+package admin
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go/service/cognitoidentityprovider/cognitoidentityprovideriface"
+	"github.com/larwef/cognito/client"
+)
+
+const opAdminSetUserPassword = "AdminSetUserPassword"
+
+// adminSetUserPasswordInput and adminSetUserPasswordOutput describe the AdminSetUserPassword operation which is
+// missing from the version of aws-sdk-go in use.
+type adminSetUserPasswordInput struct {
+	_ struct{} `type:"structure"`
+
+	Password *string `min:"6" type:"string" required:"true" sensitive:"true"`
+
+	Permanent *bool `type:"boolean"`
+
+	UserPoolId *string `min:"1" type:"string" required:"true"`
+
+	Username *string `min:"1" type:"string" required:"true" sensitive:"true"`
+}
+
+type adminSetUserPasswordOutput struct {
+	_ struct{} `type:"structure"`
+}
+
+// Admin performs administrative operations on a single user pool.
+type Admin struct {
+	userpoolID       string
+	identityProvider cognitoidentityprovideriface.CognitoIdentityProviderAPI
+	setUserPassword  func(*adminSetUserPasswordInput) error
+}
+
+// New returns a new Admin for the user pool. The AWS config has to resolve IAM credentials allowed to administer the
+// user pool.
+func New(userpoolID string, awsConfig *aws.Config) (*Admin, error) {
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error getting Cognito session: %v", err)
+	}
+
+	identityProvider := cip.New(sess)
+	return &Admin{
+		userpoolID:       userpoolID,
+		identityProvider: identityProvider,
+		setUserPassword: func(params *adminSetUserPasswordInput) error {
+			op := &request.Operation{
+				Name:       opAdminSetUserPassword,
+				HTTPMethod: "POST",
+				HTTPPath:   "/",
+			}
+			return identityProvider.NewRequest(op, params, &adminSetUserPasswordOutput{}).Send()
+		},
+	}, nil
+}
+
+// CreateUser creates a user with the given attributes. The invitation message is suppressed, so the user will not be
+// notified.
+func (a *Admin) CreateUser(username string, attributes map[string]string) error {
+	var userAttributes []*cip.AttributeType
+	for name, value := range attributes {
+		userAttributes = append(userAttributes, &cip.AttributeType{
+			Name:  aws.String(name),
+			Value: aws.String(value),
+		})
+	}
+
+	_, err := a.identityProvider.AdminCreateUser(&cip.AdminCreateUserInput{
+		MessageAction:  aws.String(cip.MessageActionTypeSuppress),
+		UserAttributes: userAttributes,
+		UserPoolId:     &a.userpoolID,
+		Username:       &username,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating user %s: %v", username, err)
+	}
+
+	return nil
+}
+
+// SetUserPassword sets a permanent password for the user. The user will not be asked to change it on first sign in.
+func (a *Admin) SetUserPassword(username, password string) error {
+	err := a.setUserPassword(&adminSetUserPasswordInput{
+		Password:   &password,
+		Permanent:  aws.Bool(true),
+		UserPoolId: &a.userpoolID,
+		Username:   &username,
+	})
+	if err != nil {
+		return fmt.Errorf("error setting password for user %s: %v", username, err)
+	}
+
+	return nil
+}
+
+// AddUserToGroup adds the user to the group.
+func (a *Admin) AddUserToGroup(username, group string) error {
+	_, err := a.identityProvider.AdminAddUserToGroup(&cip.AdminAddUserToGroupInput{
+		GroupName:  &group,
+		UserPoolId: &a.userpoolID,
+		Username:   &username,
+	})
+	if err != nil {
+		return fmt.Errorf("error adding user %s to group %s: %v", username, group, err)
+	}
+
+	return nil
+}
+
+// DeleteUser deletes the user.
+func (a *Admin) DeleteUser(username string) error {
+	_, err := a.identityProvider.AdminDeleteUser(&cip.AdminDeleteUserInput{
+		UserPoolId: &a.userpoolID,
+		Username:   &username,
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting user %s: %v", username, err)
+	}
+
+	return nil
+}
+
+// UserGlobalSignOut invalidates all tokens issued to the user.
+func (a *Admin) UserGlobalSignOut(username string) error {
+	_, err := a.identityProvider.AdminUserGlobalSignOut(&cip.AdminUserGlobalSignOutInput{
+		UserPoolId: &a.userpoolID,
+		Username:   &username,
+	})
+	if err != nil {
+		return fmt.Errorf("error signing out user %s: %v", username, err)
+	}
+
+	return nil
+}
+
+// CreateTestUser creates the user described by conf with a permanent password, adds it to the given groups and returns
+// a TokenSource for it. If any step fails, the user is deleted and the test is failed with tb.Fatalf. The returned
+// teardown function deletes the user and reports an error through tb if that fails. It is typically deferred.
+func (a *Admin) CreateTestUser(tb testing.TB, conf *client.Config, groups ...string) (ts *client.TokenSource, teardown func()) {
+	tb.Helper()
+
+	ts, err := a.createTestUser(conf, groups)
+	if err != nil {
+		tb.Fatalf("error creating test user %s: %v", conf.Username, err)
+	}
+
+	return ts, func() {
+		tb.Helper()
+		if err := a.DeleteUser(conf.Username); err != nil {
+			tb.Errorf("error deleting test user: %v", err)
+		}
+	}
+}
+
+func (a *Admin) createTestUser(conf *client.Config, groups []string) (ts *client.TokenSource, err error) {
+	if conf.UserpoolID != a.userpoolID {
+		return nil, fmt.Errorf("config user pool %s does not match admin user pool %s", conf.UserpoolID, a.userpoolID)
+	}
+
+	if err := a.CreateUser(conf.Username, nil); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+		if deleteErr := a.DeleteUser(conf.Username); deleteErr != nil {
+			err = fmt.Errorf("%v. Error deleting user: %v", err, deleteErr)
+		}
+	}()
+
+	if err := a.SetUserPassword(conf.Username, conf.Password); err != nil {
+		return nil, err
+	}
+
+	for _, group := range groups {
+		if err := a.AddUserToGroup(conf.Username, group); err != nil {
+			return nil, err
+		}
+	}
+
+	ts, err = client.NewTokenSource(conf)
+	if err != nil {
+		return nil, fmt.Errorf("error getting TokenSource: %v", err)
+	}
+
+	return ts, nil
+}
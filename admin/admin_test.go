@@ -0,0 +1,216 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go/service/cognitoidentityprovider/cognitoidentityprovideriface"
+	"github.com/larwef/cognito/client"
+)
+
+func TestAdmin_CreateUser(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	a := getAdmin(cognitoMock)
+
+	cognitoMock.adminCreateUserHandler = func(acui *cip.AdminCreateUserInput) (*cip.AdminCreateUserOutput, error) {
+		if *acui.MessageAction != cip.MessageActionTypeSuppress {
+			t.Errorf("Unexpected value: %v for MessageAction. Expected: %v", *acui.MessageAction, cip.MessageActionTypeSuppress)
+		}
+		if *acui.UserPoolId != "eu-west-1_userpoolId" {
+			t.Errorf("Unexpected value: %v for UserPoolId", *acui.UserPoolId)
+		}
+		if len(acui.UserAttributes) != 1 || *acui.UserAttributes[0].Name != "email" {
+			t.Error("UserAttributes have unexpected value")
+		}
+		return &cip.AdminCreateUserOutput{}, nil
+	}
+
+	if err := a.CreateUser("user", map[string]string{"email": "user@example.com"}); err != nil {
+		t.Errorf("CreateUser returned an error: %v", err)
+	}
+}
+
+func TestAdmin_SetUserPassword(t *testing.T) {
+	a := getAdmin(&mockCognito{})
+	a.setUserPassword = func(params *adminSetUserPasswordInput) error {
+		if !*params.Permanent {
+			t.Error("Expected password to be permanent")
+		}
+		if *params.Password != "password" {
+			t.Errorf("Unexpected value: %v for Password. Expected: %v", *params.Password, "password")
+		}
+		return nil
+	}
+
+	if err := a.SetUserPassword("user", "password"); err != nil {
+		t.Errorf("SetUserPassword returned an error: %v", err)
+	}
+}
+
+func TestAdmin_CreateTestUser(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	a := getAdmin(cognitoMock)
+
+	var calls []string
+	cognitoMock.adminCreateUserHandler = func(acui *cip.AdminCreateUserInput) (*cip.AdminCreateUserOutput, error) {
+		calls = append(calls, "create")
+		return &cip.AdminCreateUserOutput{}, nil
+	}
+	a.setUserPassword = func(*adminSetUserPasswordInput) error {
+		calls = append(calls, "password")
+		return nil
+	}
+	cognitoMock.adminAddUserToGroupHandler = func(aautgi *cip.AdminAddUserToGroupInput) (*cip.AdminAddUserToGroupOutput, error) {
+		calls = append(calls, "group:"+*aautgi.GroupName)
+		return &cip.AdminAddUserToGroupOutput{}, nil
+	}
+	cognitoMock.adminDeleteUserHandler = func(adui *cip.AdminDeleteUserInput) (*cip.AdminDeleteUserOutput, error) {
+		calls = append(calls, "delete")
+		return &cip.AdminDeleteUserOutput{}, nil
+	}
+
+	ts, teardown := a.CreateTestUser(t, getConfig(), "admins")
+	if ts == nil {
+		t.Error("Expected TokenSource to be non nil")
+	}
+
+	teardown()
+
+	expected := []string{"create", "password", "group:admins", "delete"}
+	if len(calls) != len(expected) {
+		t.Fatalf("Unexpected calls: %v. Expected: %v", calls, expected)
+	}
+	for i := range expected {
+		if calls[i] != expected[i] {
+			t.Errorf("Unexpected calls: %v. Expected: %v", calls, expected)
+		}
+	}
+}
+
+func TestAdmin_CreateTestUser_DeletesUserOnError(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	a := getAdmin(cognitoMock)
+
+	deleted := false
+	cognitoMock.adminCreateUserHandler = func(acui *cip.AdminCreateUserInput) (*cip.AdminCreateUserOutput, error) {
+		return &cip.AdminCreateUserOutput{}, nil
+	}
+	a.setUserPassword = func(*adminSetUserPasswordInput) error {
+		return errors.New("InvalidPasswordException")
+	}
+	cognitoMock.adminDeleteUserHandler = func(adui *cip.AdminDeleteUserInput) (*cip.AdminDeleteUserOutput, error) {
+		deleted = true
+		return nil, errors.New("ResourceNotFoundException")
+	}
+
+	tb := &recordingTB{TB: t}
+	runTB(func() { a.CreateTestUser(tb, getConfig()) })
+
+	if !tb.fatal {
+		t.Error("Expected CreateTestUser to fail the test")
+	}
+
+	if !deleted {
+		t.Error("Expected user to be deleted after failing to set password")
+	}
+
+	if !strings.Contains(tb.message, "InvalidPasswordException") || !strings.Contains(tb.message, "ResourceNotFoundException") {
+		t.Errorf("Expected both errors to be reported. Got: %s", tb.message)
+	}
+}
+
+func TestAdmin_CreateTestUser_TeardownReportsError(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	a := getAdmin(cognitoMock)
+
+	cognitoMock.adminCreateUserHandler = func(acui *cip.AdminCreateUserInput) (*cip.AdminCreateUserOutput, error) {
+		return &cip.AdminCreateUserOutput{}, nil
+	}
+	a.setUserPassword = func(*adminSetUserPasswordInput) error {
+		return nil
+	}
+	cognitoMock.adminDeleteUserHandler = func(adui *cip.AdminDeleteUserInput) (*cip.AdminDeleteUserOutput, error) {
+		return nil, errors.New("ResourceNotFoundException")
+	}
+
+	tb := &recordingTB{TB: t}
+	_, teardown := a.CreateTestUser(tb, getConfig())
+	teardown()
+
+	if tb.fatal || !strings.Contains(tb.message, "ResourceNotFoundException") {
+		t.Errorf("Expected teardown to report the error. Got: %s", tb.message)
+	}
+}
+
+// Mock and helper functions
+type mockCognito struct {
+	cognitoidentityprovideriface.CognitoIdentityProviderAPI
+	adminCreateUserHandler     func(*cip.AdminCreateUserInput) (*cip.AdminCreateUserOutput, error)
+	adminAddUserToGroupHandler func(*cip.AdminAddUserToGroupInput) (*cip.AdminAddUserToGroupOutput, error)
+	adminDeleteUserHandler     func(*cip.AdminDeleteUserInput) (*cip.AdminDeleteUserOutput, error)
+}
+
+func (mc *mockCognito) AdminCreateUser(acui *cip.AdminCreateUserInput) (*cip.AdminCreateUserOutput, error) {
+	return mc.adminCreateUserHandler(acui)
+}
+
+func (mc *mockCognito) AdminAddUserToGroup(aautgi *cip.AdminAddUserToGroupInput) (*cip.AdminAddUserToGroupOutput, error) {
+	return mc.adminAddUserToGroupHandler(aautgi)
+}
+
+func (mc *mockCognito) AdminDeleteUser(adui *cip.AdminDeleteUserInput) (*cip.AdminDeleteUserOutput, error) {
+	return mc.adminDeleteUserHandler(adui)
+}
+
+// recordingTB records failures instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	fatal   bool
+	message string
+}
+
+func (tb *recordingTB) Helper() {}
+
+func (tb *recordingTB) Errorf(format string, args ...interface{}) {
+	tb.message = fmt.Sprintf(format, args...)
+}
+
+func (tb *recordingTB) Fatalf(format string, args ...interface{}) {
+	tb.fatal = true
+	tb.message = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// runTB runs f in a separate goroutine, so the test continues after f calls Fatalf.
+func runTB(f func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	<-done
+}
+
+func getAdmin(mock cognitoidentityprovideriface.CognitoIdentityProviderAPI) *Admin {
+	return &Admin{
+		userpoolID:       "eu-west-1_userpoolId",
+		identityProvider: mock,
+	}
+}
+
+func getConfig() *client.Config {
+	return &client.Config{
+		UserpoolID: "eu-west-1_userpoolId",
+		ClientID:   "clientId",
+		Username:   "user",
+		Password:   "password",
+		AWSConfig: &aws.Config{
+			Region: aws.String("eu-west-1"),
+		},
+	}
+}
@@ -0,0 +1,17 @@
+package client
+
+import (
+	"time"
+
+	"github.com/larwef/cognito/internal/clock"
+)
+
+// Clock tells the current time. Set it on Config to control token expiry and refresh deterministically, eg. in tests.
+type Clock = clock.Clock
+
+// ClockFunc is an adapter to allow the use of ordinary functions as Clocks.
+type ClockFunc = clock.Func
+
+func (ts *TokenSource) now() time.Time {
+	return clock.Now(ts.config.Clock)
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+)
+
+func TestTokenSource_Clock(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getTokenSource(cognitoMock)
+
+	now := time.Date(2019, 4, 19, 20, 0, 0, 0, time.UTC)
+	ts.config.Clock = ClockFunc(func() time.Time { return now })
+
+	cognitoMock.respondToAuthChallengeHandler = func(rac *cip.RespondToAuthChallengeInput) (*cip.RespondToAuthChallengeOutput, error) {
+		if timestamp := aws.StringValue(rac.ChallengeResponses["TIMESTAMP"]); timestamp != "Fri Apr 19 20:00:00 UTC 2019" {
+			t.Errorf("Unexpected value: %s for TIMESTAMP", timestamp)
+		}
+
+		return &cip.RespondToAuthChallengeOutput{
+			AuthenticationResult: &cip.AuthenticationResultType{
+				AccessToken:  aws.String("AccessToken"),
+				IdToken:      aws.String("IDToken"),
+				RefreshToken: aws.String("RefreshToken"),
+				ExpiresIn:    aws.Int64(3600),
+				TokenType:    aws.String("Bearer"),
+			},
+		}, nil
+	}
+
+	tkn, err := ts.GetToken()
+	if err != nil {
+		t.Fatalf("GetToken returned an error: %v", err)
+	}
+
+	if !tkn.Expiration.Equal(now.Add(time.Hour)) {
+		t.Errorf("Unexpected value: %v for Expiration. Expected: %v", tkn.Expiration, now.Add(time.Hour))
+	}
+
+	now = now.Add(59 * time.Minute)
+	if tkn, err = ts.GetToken(); err != nil || tkn.AccessToken != "AccessToken" {
+		t.Errorf("Expected cached token. Got: %v, %v", tkn, err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if tkn, err = ts.GetToken(); err != nil || tkn.AccessToken != "refreshedAccessToken" {
+		t.Errorf("Expected refreshed token. Got: %v, %v", tkn, err)
+	}
+}
+
+func TestToken_Clock(t *testing.T) {
+	now := time.Date(2019, 4, 19, 20, 0, 0, 0, time.UTC)
+	ts := getTokenSource(nil)
+	ts.config.Clock = ClockFunc(func() time.Time { return now })
+	ts.SetToken(&Token{AccessToken: "AccessToken", IDToken: "IDToken", Expiration: now.Add(time.Hour)})
+
+	tkn, err := ts.GetToken()
+	if err != nil {
+		t.Fatalf("GetToken returned an error: %v", err)
+	}
+
+	if !tkn.Valid() || tkn.ExpiresIn() != time.Hour {
+		t.Errorf("Unexpected value: %v, %v for Valid and ExpiresIn. Expected: true, %v", tkn.Valid(), tkn.ExpiresIn(), time.Hour)
+	}
+
+	// The Token expires by the Clock of the TokenSource, also when reused by a ReuseTokenSource.
+	src := &countingTokenProvider{expiresIn: time.Hour}
+	rts := ReuseTokenSource(tkn, src)
+
+	now = now.Add(2 * time.Hour)
+	if tkn.Valid() || tkn.ExpiresIn() != -time.Hour {
+		t.Errorf("Unexpected value: %v, %v for Valid and ExpiresIn. Expected: false, %v", tkn.Valid(), tkn.ExpiresIn(), -time.Hour)
+	}
+
+	if _, err = rts.Token(context.Background()); err != nil || src.calls != 1 {
+		t.Errorf("Expected a new token from the underlying source. Got %d calls and error: %v", src.calls, err)
+	}
+}
@@ -5,27 +5,106 @@ import (
 	"net/http"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/cognitoidentityprovider/cognitoidentityprovideriface"
 )
 
 // Config holds configuration info for the cognito http client
 type Config struct {
-	UserpoolID               string
-	ClientID                 string
-	Username                 string
-	Password                 string
+	UserpoolID string
+	ClientID   string
+	Username   string
+	Password   string
+	// AWSConfig configures the aws-sdk-go clients. Its Credentials are not used, since the Cognito operations are
+	// unauthenticated. The region defaults to the region of UserpoolID.
 	AWSConfig                *aws.Config
 	RequireTransportSecurity bool
+	// RevokeOnClose makes TokenSource.Close revoke the refresh token instead of only forgetting it.
+	RevokeOnClose bool
+	// TokenProvider is used by Client and IAMClient instead of a TokenSource authenticating with Username and
+	// Password when set.
+	TokenProvider TokenProvider
+	// IdentityPoolID is the identity pool used by IAMClient to exchange tokens for AWS credentials.
+	IdentityPoolID string
+	// Lightweight makes TokenSource call Cognito with a minimal JSON client using HTTPClient instead of the aws-sdk-go
+	// client. AWSConfig is not used and the region is taken from UserpoolID. The package still depends on aws-sdk-go,
+	// so it does not make programs smaller.
+	Lightweight bool
+	// HTTPClient is used by the lightweight client. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+	// Endpoint overrides the Cognito endpoint used by the lightweight client, eg. to use an emulator.
+	Endpoint string
+	// IdentityProvider is used by TokenSource to call Cognito when set, eg. a fake user pool from the cognitotest
+	// package. AWSConfig and Lightweight are ignored. It must implement TokenRevoker for SignOut to revoke the refresh
+	// token.
+	IdentityProvider cognitoidentityprovideriface.CognitoIdentityProviderAPI
+	// Observer receives authentication events from TokenSource, eg. an ExpvarObserver for metrics.
+	Observer Observer
+	// Logger receives log records about the authentication flow, eg. a *slog.Logger. Tokens and passwords are never
+	// logged.
+	Logger Logger
+	// Clock is used by TokenSource to tell the time when checking token expiry and signing challenges. If nil, the
+	// system clock is used.
+	Clock Clock
+	// AllowedHosts restricts which hosts the http.Client from Client sends tokens to. See Transport.AllowedHosts.
+	AllowedHosts []string
 }
 
 // Client returns a new http.Client which will handle authentication with Cognito
 func (c *Config) Client() (*http.Client, error) {
-	ts, err := NewTokenSource(c)
+	tp, err := c.tokenProvider()
 	if err != nil {
-		return nil, fmt.Errorf("error getting TokenSource: %v", err)
+		return nil, err
 	}
+
 	return &http.Client{
 		Transport: &Transport{
-			Source: ts,
+			Source:       tp,
+			AllowedHosts: c.AllowedHosts,
 		},
 	}, nil
 }
+
+// IAMClient returns a new http.Client which will sign requests with AWS credentials for the service. The credentials are
+// obtained from the identity pool in IdentityPoolID by logging in to the user pool. Use it for APIs with IAM
+// authorization, eg. API Gateway with AWS_IAM using the service DefaultSigningService.
+func (c *Config) IAMClient(service string) (*http.Client, error) {
+	tp, err := c.tokenProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := NewIdentityPoolProvider(c.IdentityPoolID, c.UserpoolID, tp, c.AWSConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error getting IdentityPoolProvider: %v", err)
+	}
+
+	region, err := userpoolRegion(c.UserpoolID)
+	if err != nil {
+		return nil, err
+	}
+	if c.AWSConfig != nil && aws.StringValue(c.AWSConfig.Region) != "" {
+		region = aws.StringValue(c.AWSConfig.Region)
+	}
+
+	return &http.Client{
+		Transport: &SigV4Transport{
+			Credentials: credentials.NewCredentials(provider),
+			Service:     service,
+			Region:      region,
+		},
+	}, nil
+}
+
+func (c *Config) tokenProvider() (TokenProvider, error) {
+	if c.TokenProvider != nil {
+		return c.TokenProvider, nil
+	}
+
+	ts, err := NewTokenSource(c)
+	if err != nil {
+		return nil, fmt.Errorf("error getting TokenSource: %v", err)
+	}
+
+	return ts, nil
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInsecureChannel is returned when PerRPCCredentials is asked to attach a token to a call on a channel which is not
+// secure.
+var ErrInsecureChannel = errors.New("cognito: refusing to send token over insecure channel")
+
+// PerRPCCredentials attaches tokens from a TokenSource to gRPC calls. It implements the PerRPCCredentials interface
+// from google.golang.org/grpc/credentials without depending on gRPC, eg:
+//
+//	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(tlsCreds), grpc.WithPerRPCCredentials(&client.PerRPCCredentials{
+//		Source: tokenSource,
+//	}))
+//
+// The zero value of AllowInsecure makes the credentials require transport security, so gRPC will refuse to use them on
+// a plaintext connection.
+type PerRPCCredentials struct {
+	// Source supplies the tokens attached to the calls.
+	Source TokenProvider
+
+	// SelectToken returns the token to send to the service identified by uri. If nil, SelectIDToken is used.
+	SelectToken func(uri string, token *Token) string
+
+	// AllowInsecure allows tokens to be attached to calls on plaintext channels.
+	AllowInsecure bool
+
+	// ChannelSecure reports whether the channel a call is made on is secure. When set, it is checked before every call
+	// unless AllowInsecure is set. With gRPC it can be implemented as:
+	//
+	//	func(ctx context.Context) bool {
+	//		ri, ok := credentials.RequestInfoFromContext(ctx)
+	//		return ok && credentials.CheckSecurityLevel(ri.AuthInfo, credentials.PrivacyAndIntegrity) == nil
+	//	}
+	ChannelSecure func(ctx context.Context) bool
+}
+
+// SelectIDToken selects the ID token for every service.
+func SelectIDToken(uri string, token *Token) string {
+	return token.IDToken
+}
+
+// SelectAccessToken selects the access token for every service.
+func SelectAccessToken(uri string, token *Token) string {
+	return token.AccessToken
+}
+
+// GetRequestMetadata returns the authorization metadata for a call to the service identified by uri. ctx is passed on
+// to the TokenProvider, so the deadline of the call also applies to obtaining the token.
+func (c *PerRPCCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	if c.Source == nil {
+		return nil, errors.New("cognito: PerRPCCredentials' TokenProvider is nil")
+	}
+
+	if !c.AllowInsecure && c.ChannelSecure != nil && !c.ChannelSecure(ctx) {
+		return nil, ErrInsecureChannel
+	}
+
+	token, err := c.Source.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var target string
+	if len(uri) > 0 {
+		target = uri[0]
+	}
+
+	selectToken := c.SelectToken
+	if selectToken == nil {
+		selectToken = SelectIDToken
+	}
+
+	return map[string]string{
+		metadataAuthorizationFieldName: token.TokenType + " " + selectToken(target, token),
+	}, nil
+}
+
+// RequireTransportSecurity reports whether transport security is required. It is true unless AllowInsecure is set.
+func (c *PerRPCCredentials) RequireTransportSecurity() bool {
+	return !c.AllowInsecure
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPerRPCCredentials_GetRequestMetadata(t *testing.T) {
+	ts := getAuthenticatedTokenSource(&mockCognito{})
+	ts.tkn.TokenType = "Bearer"
+
+	creds := &PerRPCCredentials{Source: ts}
+
+	metadata, err := creds.GetRequestMetadata(context.Background(), "https://service.example.com/package.Service")
+	if err != nil {
+		t.Errorf("GetRequestMetadata returned an error: %v", err)
+	}
+
+	if metadata["authorization"] != "Bearer IDToken" {
+		t.Errorf("Unexpected value: %v for authorization. Expected: %v", metadata["authorization"], "Bearer IDToken")
+	}
+
+	if !creds.RequireTransportSecurity() {
+		t.Error("Expected RequireTransportSecurity to default to true")
+	}
+}
+
+func TestPerRPCCredentials_GetRequestMetadata_SelectToken(t *testing.T) {
+	ts := getAuthenticatedTokenSource(&mockCognito{})
+	ts.tkn.TokenType = "Bearer"
+
+	creds := &PerRPCCredentials{
+		Source: ts,
+		SelectToken: func(uri string, token *Token) string {
+			if strings.HasPrefix(uri, "https://api.example.com") {
+				return SelectAccessToken(uri, token)
+			}
+			return SelectIDToken(uri, token)
+		},
+	}
+
+	metadata, err := creds.GetRequestMetadata(context.Background(), "https://api.example.com/package.Service")
+	if err != nil {
+		t.Errorf("GetRequestMetadata returned an error: %v", err)
+	}
+
+	if metadata["authorization"] != "Bearer AccessToken" {
+		t.Errorf("Unexpected value: %v for authorization. Expected: %v", metadata["authorization"], "Bearer AccessToken")
+	}
+
+	metadata, err = creds.GetRequestMetadata(context.Background(), "https://other.example.com/package.Service")
+	if err != nil {
+		t.Errorf("GetRequestMetadata returned an error: %v", err)
+	}
+
+	if metadata["authorization"] != "Bearer IDToken" {
+		t.Errorf("Unexpected value: %v for authorization. Expected: %v", metadata["authorization"], "Bearer IDToken")
+	}
+}
+
+func TestPerRPCCredentials_GetRequestMetadata_InsecureChannel(t *testing.T) {
+	ts := getAuthenticatedTokenSource(&mockCognito{})
+	insecure := func(context.Context) bool { return false }
+
+	creds := &PerRPCCredentials{Source: ts, ChannelSecure: insecure}
+	if _, err := creds.GetRequestMetadata(context.Background()); err != ErrInsecureChannel {
+		t.Errorf("expected GetRequestMetadata to return error: %v but got: %v", ErrInsecureChannel, err)
+	}
+
+	creds = &PerRPCCredentials{Source: ts, ChannelSecure: insecure, AllowInsecure: true}
+	if _, err := creds.GetRequestMetadata(context.Background()); err != nil {
+		t.Errorf("GetRequestMetadata returned an error: %v", err)
+	}
+
+	if creds.RequireTransportSecurity() {
+		t.Error("Expected RequireTransportSecurity to be false when AllowInsecure is set")
+	}
+}
+
+func TestPerRPCCredentials_GetRequestMetadata_ContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	creds := &PerRPCCredentials{Source: blockingTokenProvider{}}
+	if _, err := creds.GetRequestMetadata(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected GetRequestMetadata to return error: %v but got: %v", context.DeadlineExceeded, err)
+	}
+}
+
+// blockingTokenProvider never returns a token before ctx is done.
+type blockingTokenProvider struct{}
+
+func (blockingTokenProvider) Token(ctx context.Context) (*Token, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+	"github.com/larwef/cognito/internal/idp"
+)
+
+// Maximum number of devices Cognito returns per ListDevices call.
+const listDevicesLimit int64 = 60
+
+// Device holds information about a device remembered for the authenticated user.
+type Device struct {
+	Key                   string
+	Attributes            map[string]string
+	CreateDate            time.Time
+	LastAuthenticatedDate time.Time
+	LastModifiedDate      time.Time
+}
+
+func newDevice(d *idp.Device) *Device {
+	device := &Device{
+		Key:                   d.DeviceKey,
+		Attributes:            make(map[string]string),
+		CreateDate:            epochTime(d.DeviceCreateDate),
+		LastAuthenticatedDate: epochTime(d.DeviceLastAuthenticatedDate),
+		LastModifiedDate:      epochTime(d.DeviceLastModifiedDate),
+	}
+	for _, attribute := range d.DeviceAttributes {
+		device.Attributes[attribute.Name] = attribute.Value
+	}
+
+	return device
+}
+
+// epochTime returns the date of seconds since the Unix epoch, as sent by Cognito. 0 is the zero time. The fraction is
+// rounded to microseconds, which a float64 holds for current dates.
+func epochTime(seconds float64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+
+	sec := math.Floor(seconds)
+	usec := math.Round((seconds - sec) * float64(time.Second/time.Microsecond))
+	return time.Unix(int64(sec), int64(usec)*int64(time.Microsecond))
+}
+
+// ListDevices returns all devices tracked for the authenticated user, following pagination until every device is
+// retrieved. The pagination stops when ctx is done.
+func (ts *TokenSource) ListDevices(ctx context.Context) ([]*Device, error) {
+	var devices []*Device
+	var paginationToken string
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		accessToken, err := ts.accessToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := ts.identityProvider.ListDevices(ctx, &idp.ListDevicesInput{
+			AccessToken:     accessToken,
+			Limit:           listDevicesLimit,
+			PaginationToken: paginationToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing devices: %v", err)
+		}
+
+		for i := range res.Devices {
+			devices = append(devices, newDevice(&res.Devices[i]))
+		}
+
+		if res.PaginationToken == "" {
+			return devices, nil
+		}
+		paginationToken = res.PaginationToken
+	}
+}
+
+// GetDevice returns the device with the given device key.
+func (ts *TokenSource) GetDevice(ctx context.Context, deviceKey string) (*Device, error) {
+	accessToken, err := ts.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := ts.identityProvider.GetDevice(ctx, &idp.DeviceInput{
+		AccessToken: accessToken,
+		DeviceKey:   deviceKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting device %s: %v", deviceKey, err)
+	}
+
+	if res.Device == nil {
+		return nil, fmt.Errorf("error getting device %s: no device returned", deviceKey)
+	}
+
+	return newDevice(res.Device), nil
+}
+
+// UpdateDeviceStatus sets whether the device with the given device key is remembered.
+func (ts *TokenSource) UpdateDeviceStatus(ctx context.Context, deviceKey string, remembered bool) error {
+	accessToken, err := ts.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	status := cip.DeviceRememberedStatusTypeNotRemembered
+	if remembered {
+		status = cip.DeviceRememberedStatusTypeRemembered
+	}
+
+	err = ts.identityProvider.UpdateDeviceStatus(ctx, &idp.UpdateDeviceStatusInput{
+		AccessToken:            accessToken,
+		DeviceKey:              deviceKey,
+		DeviceRememberedStatus: status,
+	})
+	if err != nil {
+		return fmt.Errorf("error updating status of device %s: %v", deviceKey, err)
+	}
+
+	return nil
+}
+
+// ForgetDevice stops tracking the device with the given device key.
+func (ts *TokenSource) ForgetDevice(ctx context.Context, deviceKey string) error {
+	accessToken, err := ts.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = ts.identityProvider.ForgetDevice(ctx, &idp.DeviceInput{
+		AccessToken: accessToken,
+		DeviceKey:   deviceKey,
+	})
+	if err != nil {
+		return fmt.Errorf("error forgetting device %s: %v", deviceKey, err)
+	}
+
+	return nil
+}
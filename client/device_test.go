@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+)
+
+func TestTokenSource_ListDevices(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	cognitoMock.listDevicesHandler = func(ldi *cip.ListDevicesInput) (*cip.ListDevicesOutput, error) {
+		if ldi.PaginationToken == nil {
+			return &cip.ListDevicesOutput{
+				Devices:         []*cip.DeviceType{{DeviceKey: aws.String("device1")}},
+				PaginationToken: aws.String("page2"),
+			}, nil
+		}
+
+		if *ldi.PaginationToken != "page2" {
+			t.Errorf("Unexpected value: %v for PaginationToken. Expected: %v", *ldi.PaginationToken, "page2")
+		}
+		return &cip.ListDevicesOutput{
+			Devices: []*cip.DeviceType{{DeviceKey: aws.String("device2")}},
+		}, nil
+	}
+
+	devices, err := ts.ListDevices(context.Background())
+	if err != nil {
+		t.Errorf("ListDevices returned an error: %v", err)
+	}
+
+	if len(devices) != 2 {
+		t.Fatalf("Unexpected number of devices %d", len(devices))
+	}
+
+	if devices[0].Key != "device1" || devices[1].Key != "device2" {
+		t.Error("Devices have unexpected keys")
+	}
+}
+
+func TestTokenSource_GetDevice(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	created := time.Date(2019, 4, 16, 8, 43, 29, 0, time.UTC)
+	cognitoMock.getDeviceHandler = func(gdi *cip.GetDeviceInput) (*cip.GetDeviceOutput, error) {
+		return &cip.GetDeviceOutput{
+			Device: &cip.DeviceType{
+				DeviceKey:        gdi.DeviceKey,
+				DeviceCreateDate: &created,
+				DeviceAttributes: []*cip.AttributeType{
+					{Name: aws.String("device_name"), Value: aws.String("laptop")},
+				},
+			},
+		}, nil
+	}
+
+	device, err := ts.GetDevice(context.Background(), "device1")
+	if err != nil {
+		t.Errorf("GetDevice returned an error: %v", err)
+	}
+
+	if device.Key != "device1" {
+		t.Errorf("Unexpected value: %v for Key. Expected: %v", device.Key, "device1")
+	}
+
+	if !device.CreateDate.Equal(created) {
+		t.Errorf("Unexpected value: %v for CreateDate. Expected: %v", device.CreateDate, created)
+	}
+
+	if device.Attributes["device_name"] != "laptop" {
+		t.Error("Attributes have unexpected value")
+	}
+}
+
+func TestTokenSource_UpdateDeviceStatus(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	cognitoMock.updateDeviceStatusHandler = func(udsi *cip.UpdateDeviceStatusInput) (*cip.UpdateDeviceStatusOutput, error) {
+		if *udsi.DeviceRememberedStatus != cip.DeviceRememberedStatusTypeNotRemembered {
+			t.Errorf("Unexpected value: %v for DeviceRememberedStatus", *udsi.DeviceRememberedStatus)
+		}
+		return &cip.UpdateDeviceStatusOutput{}, nil
+	}
+
+	if err := ts.UpdateDeviceStatus(context.Background(), "device1", false); err != nil {
+		t.Errorf("UpdateDeviceStatus returned an error: %v", err)
+	}
+}
+
+func TestTokenSource_ForgetDevice(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	cognitoMock.forgetDeviceHandler = func(fdi *cip.ForgetDeviceInput) (*cip.ForgetDeviceOutput, error) {
+		if *fdi.DeviceKey != "device1" {
+			t.Errorf("Unexpected value: %v for DeviceKey. Expected: %v", *fdi.DeviceKey, "device1")
+		}
+		return &cip.ForgetDeviceOutput{}, nil
+	}
+
+	if err := ts.ForgetDevice(context.Background(), "device1"); err != nil {
+		t.Errorf("ForgetDevice returned an error: %v", err)
+	}
+}
+
+func TestTokenSource_ListDevices_Canceled(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	cognitoMock.listDevicesHandler = func(ldi *cip.ListDevicesInput) (*cip.ListDevicesOutput, error) {
+		calls++
+		cancel()
+		return &cip.ListDevicesOutput{
+			Devices:         []*cip.DeviceType{{DeviceKey: aws.String("device1")}},
+			PaginationToken: aws.String("next"),
+		}, nil
+	}
+
+	if _, err := ts.ListDevices(ctx); err != context.Canceled {
+		t.Errorf("Unexpected error: %v. Expected: %v", err, context.Canceled)
+	}
+
+	if calls != 1 {
+		t.Errorf("Unexpected number of calls to ListDevices: %d. Expected: %d", calls, 1)
+	}
+}
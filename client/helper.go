@@ -0,0 +1,153 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// ExecCredentialAPIVersion is the API version of the ExecCredential written by CredentialHelper.
+const ExecCredentialAPIVersion = "client.authentication.k8s.io/v1beta1"
+
+// CredentialHelper writes tokens from a TokenProvider in the formats expected by programs using external credential
+// helpers: kubectl exec plugins, Docker credential helpers and git credential helpers.
+type CredentialHelper struct {
+	// Source supplies the tokens.
+	Source TokenProvider
+
+	// SelectToken returns the token to write for the server identified by uri. uri is empty for kubectl. If nil,
+	// SelectIDToken is used.
+	SelectToken func(uri string, token *Token) string
+
+	// Username is written as the username for Docker and git, which both require one.
+	Username string
+}
+
+// ExecCredential writes an ExecCredential for a kubectl exec credential plugin. The expiration of the Token is
+// included, so kubectl caches the credential until it expires.
+func (h *CredentialHelper) ExecCredential(ctx context.Context, w io.Writer) error {
+	tkn, err := h.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	type status struct {
+		Token               string `json:"token"`
+		ExpirationTimestamp string `json:"expirationTimestamp,omitempty"`
+	}
+
+	cred := struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Status     status `json:"status"`
+	}{
+		APIVersion: ExecCredentialAPIVersion,
+		Kind:       "ExecCredential",
+		Status:     status{Token: h.selectToken("", tkn)},
+	}
+
+	if !tkn.Expiration.IsZero() {
+		cred.Status.ExpirationTimestamp = tkn.Expiration.UTC().Format(time.RFC3339)
+	}
+
+	return json.NewEncoder(w).Encode(cred)
+}
+
+// DockerCredential implements the get command of a Docker credential helper. It reads the server URL from r and writes
+// the credential to w. The Docker protocol has no expiry, so Docker asks the helper for every pull and push.
+func (h *CredentialHelper) DockerCredential(ctx context.Context, r io.Reader, w io.Writer) error {
+	b, err := ioutil.ReadAll(io.LimitReader(r, 4096))
+	if err != nil {
+		return fmt.Errorf("error reading server URL: %v", err)
+	}
+
+	serverURL := strings.TrimSpace(string(b))
+	if serverURL == "" {
+		return errors.New("no server URL given")
+	}
+
+	tkn, err := h.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(struct {
+		ServerURL string
+		Username  string
+		Secret    string
+	}{serverURL, h.Username, h.selectToken(serverURL, tkn)})
+}
+
+// GitCredential implements the get action of a git credential helper. It reads the attributes of the requested
+// credential from r and writes the username, the token as password and password_expiry_utc to w.
+func (h *CredentialHelper) GitCredential(ctx context.Context, r io.Reader, w io.Writer) error {
+	attrs := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+
+		split := strings.SplitN(line, "=", 2)
+		if len(split) == 2 {
+			attrs[split[0]] = split[1]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading credential description: %v", err)
+	}
+
+	tkn, err := h.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	uri := attrs["protocol"] + "://" + attrs["host"]
+	if path := attrs["path"]; path != "" {
+		uri += "/" + path
+	}
+
+	username := h.Username
+	if u := attrs["username"]; u != "" {
+		username = u
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "username=%s\n", username)
+	fmt.Fprintf(&sb, "password=%s\n", h.selectToken(uri, tkn))
+	if !tkn.Expiration.IsZero() {
+		fmt.Fprintf(&sb, "password_expiry_utc=%d\n", tkn.Expiration.Unix())
+	}
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+func (h *CredentialHelper) token(ctx context.Context) (*Token, error) {
+	if h.Source == nil {
+		return nil, errors.New("cognito: CredentialHelper's TokenProvider is nil")
+	}
+
+	tkn, err := h.Source.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting token: %v", err)
+	}
+
+	return tkn, nil
+}
+
+func (h *CredentialHelper) selectToken(uri string, tkn *Token) string {
+	if h.SelectToken != nil {
+		return h.SelectToken(uri, tkn)
+	}
+
+	return SelectIDToken(uri, tkn)
+}
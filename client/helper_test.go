@@ -0,0 +1,97 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func getCredentialHelper() *CredentialHelper {
+	return &CredentialHelper{
+		Source: StaticTokenSource(&Token{
+			AccessToken: "AccessToken",
+			IDToken:     "IDToken",
+			TokenType:   "Bearer",
+			Expiration:  time.Date(2019, 4, 19, 20, 0, 0, 0, time.UTC),
+		}),
+		Username: "user",
+	}
+}
+
+func TestCredentialHelper_ExecCredential(t *testing.T) {
+	var buf bytes.Buffer
+	if err := getCredentialHelper().ExecCredential(context.Background(), &buf); err != nil {
+		t.Fatalf("ExecCredential returned an error: %v", err)
+	}
+
+	var cred struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Status     struct {
+			Token               string `json:"token"`
+			ExpirationTimestamp string `json:"expirationTimestamp"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &cred); err != nil {
+		t.Fatalf("error unmarshalling ExecCredential: %v", err)
+	}
+
+	if cred.APIVersion != ExecCredentialAPIVersion || cred.Kind != "ExecCredential" {
+		t.Errorf("Unexpected apiVersion and kind: %s %s", cred.APIVersion, cred.Kind)
+	}
+
+	if cred.Status.Token != "IDToken" {
+		t.Errorf("Unexpected value: %s for token. Expected: %s", cred.Status.Token, "IDToken")
+	}
+
+	if cred.Status.ExpirationTimestamp != "2019-04-19T20:00:00Z" {
+		t.Errorf("Unexpected value: %s for expirationTimestamp. Expected: %s", cred.Status.ExpirationTimestamp, "2019-04-19T20:00:00Z")
+	}
+}
+
+func TestCredentialHelper_DockerCredential(t *testing.T) {
+	helper := getCredentialHelper()
+	helper.SelectToken = SelectAccessToken
+
+	var buf bytes.Buffer
+	if err := helper.DockerCredential(context.Background(), strings.NewReader("registry.example.com\n"), &buf); err != nil {
+		t.Fatalf("DockerCredential returned an error: %v", err)
+	}
+
+	expected := `{"ServerURL":"registry.example.com","Username":"user","Secret":"AccessToken"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected value: %s. Expected: %s", buf.String(), expected)
+	}
+
+	if err := helper.DockerCredential(context.Background(), strings.NewReader(""), &buf); err == nil {
+		t.Error("Expected an error without server URL")
+	}
+}
+
+func TestCredentialHelper_GitCredential(t *testing.T) {
+	helper := getCredentialHelper()
+
+	var uri string
+	helper.SelectToken = func(u string, token *Token) string {
+		uri = u
+		return token.IDToken
+	}
+
+	var buf bytes.Buffer
+	input := "protocol=https\nhost=git.example.com\npath=repo.git\n\n"
+	if err := helper.GitCredential(context.Background(), strings.NewReader(input), &buf); err != nil {
+		t.Fatalf("GitCredential returned an error: %v", err)
+	}
+
+	expected := "username=user\npassword=IDToken\npassword_expiry_utc=1555704000\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected value: %q. Expected: %q", buf.String(), expected)
+	}
+
+	if uri != "https://git.example.com/repo.git" {
+		t.Errorf("Unexpected value: %s for uri. Expected: %s", uri, "https://git.example.com/repo.git")
+	}
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/cognitoidentity"
+	"github.com/aws/aws-sdk-go/service/cognitoidentity/cognitoidentityiface"
+)
+
+// IdentityPoolProviderName is the ProviderName of credentials retrieved by IdentityPoolProvider.
+const IdentityPoolProviderName = "CognitoIdentityPoolProvider"
+
+// DefaultExpiryWindow is how long before expiry IdentityPoolProvider retrieves new credentials, unless configured
+// otherwise.
+const DefaultExpiryWindow = 1 * time.Minute
+
+var _ credentials.Provider = (*IdentityPoolProvider)(nil)
+
+// IdentityPoolProvider retrieves temporary AWS credentials from a Cognito identity pool, logging in with the ID token
+// from a user pool. It implements credentials.Provider from aws-sdk-go and should be wrapped with
+// credentials.NewCredentials, which caches the credentials until IdentityPoolProvider reports them as expired, eg:
+//
+//	awsConf := &aws.Config{
+//		Credentials: credentials.NewCredentials(provider),
+//	}
+type IdentityPoolProvider struct {
+	credentials.Expiry
+
+	// ExpiryWindow makes the credentials expire early, so they are refreshed before they stop working. Defaults to
+	// DefaultExpiryWindow if zero.
+	ExpiryWindow time.Duration
+
+	identityPoolID string
+	loginProvider  string
+	source         TokenProvider
+	identity       cognitoidentityiface.CognitoIdentityAPI
+
+	mu         sync.Mutex // guards identityID
+	identityID string
+}
+
+// NewIdentityPoolProvider returns a new IdentityPoolProvider for the identity pool, using tokens from src which have to
+// be issued by the user pool. The region of the identity pool is taken from awsConfig, or from the user pool ID if not
+// set. Credentials in awsConfig are not used since the identity pool operations are unauthenticated.
+func NewIdentityPoolProvider(identityPoolID, userpoolID string, src TokenProvider, awsConfig *aws.Config) (*IdentityPoolProvider, error) {
+	region, err := userpoolRegion(userpoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IdentityPoolProvider{
+		identityPoolID: identityPoolID,
+		loginProvider:  fmt.Sprintf("cognito-idp.%s.amazonaws.com/%s", region, userpoolID),
+		source:         src,
+		identity:       cognitoidentity.New(newSDKConfigProvider(awsConfig, region)),
+	}, nil
+}
+
+// Retrieve implements credentials.Provider. It gets a token from the TokenProvider and exchanges it for credentials.
+// The identity ID is looked up on the first call and reused after.
+func (p *IdentityPoolProvider) Retrieve() (credentials.Value, error) {
+	ctx := context.Background()
+
+	token, err := p.source.Token(ctx)
+	if err != nil {
+		return credentials.Value{ProviderName: IdentityPoolProviderName}, fmt.Errorf("error getting Token: %v", err)
+	}
+	logins := map[string]*string{
+		p.loginProvider: aws.String(token.IDToken),
+	}
+
+	identityID, err := p.getIdentityID(ctx, logins)
+	if err != nil {
+		return credentials.Value{ProviderName: IdentityPoolProviderName}, err
+	}
+
+	res, err := p.identity.GetCredentialsForIdentityWithContext(ctx, &cognitoidentity.GetCredentialsForIdentityInput{
+		IdentityId: aws.String(identityID),
+		Logins:     logins,
+	})
+	if err != nil {
+		return credentials.Value{ProviderName: IdentityPoolProviderName}, fmt.Errorf("error getting credentials for identity: %v", err)
+	}
+
+	window := p.ExpiryWindow
+	if window == 0 {
+		window = DefaultExpiryWindow
+	}
+	p.SetExpiration(aws.TimeValue(res.Credentials.Expiration), window)
+
+	return credentials.Value{
+		AccessKeyID:     aws.StringValue(res.Credentials.AccessKeyId),
+		SecretAccessKey: aws.StringValue(res.Credentials.SecretKey),
+		SessionToken:    aws.StringValue(res.Credentials.SessionToken),
+		ProviderName:    IdentityPoolProviderName,
+	}, nil
+}
+
+// IdentityID returns the identity ID of the user in the identity pool. It is empty until credentials have been
+// retrieved.
+func (p *IdentityPoolProvider) IdentityID() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.identityID
+}
+
+func (p *IdentityPoolProvider) getIdentityID(ctx context.Context, logins map[string]*string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.identityID != "" {
+		return p.identityID, nil
+	}
+
+	res, err := p.identity.GetIdWithContext(ctx, &cognitoidentity.GetIdInput{
+		IdentityPoolId: aws.String(p.identityPoolID),
+		Logins:         logins,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error getting identity ID: %v", err)
+	}
+	p.identityID = aws.StringValue(res.IdentityId)
+
+	return p.identityID, nil
+}
+
+// userpoolRegion returns the region part of a user pool ID like eu-west-1_abc123.
+func userpoolRegion(userpoolID string) (string, error) {
+	split := strings.SplitN(userpoolID, "_", 2)
+	if len(split) != 2 || split[0] == "" {
+		return "", fmt.Errorf("invalid user pool ID: %s", userpoolID)
+	}
+
+	return split[0], nil
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cognitoidentity"
+	"github.com/aws/aws-sdk-go/service/cognitoidentity/cognitoidentityiface"
+)
+
+func TestIdentityPoolProvider_Retrieve(t *testing.T) {
+	identityMock := &mockCognitoIdentity{}
+	provider := getIdentityPoolProvider(identityMock)
+
+	loginProvider := "cognito-idp.eu-west-1.amazonaws.com/eu-west-1_userpoolId"
+	expiration := time.Now().Add(1 * time.Hour)
+
+	getIDCalls := 0
+	identityMock.getIDHandler = func(gii *cognitoidentity.GetIdInput) (*cognitoidentity.GetIdOutput, error) {
+		getIDCalls++
+		if *gii.IdentityPoolId != "eu-west-1:identityPoolId" {
+			t.Errorf("Unexpected value: %v for IdentityPoolId", *gii.IdentityPoolId)
+		}
+		if aws.StringValue(gii.Logins[loginProvider]) != "IDToken" {
+			t.Errorf("Unexpected logins: %v", aws.StringValueMap(gii.Logins))
+		}
+		return &cognitoidentity.GetIdOutput{IdentityId: aws.String("identityId")}, nil
+	}
+	identityMock.getCredentialsForIdentityHandler = func(gcfii *cognitoidentity.GetCredentialsForIdentityInput) (*cognitoidentity.GetCredentialsForIdentityOutput, error) {
+		if *gcfii.IdentityId != "identityId" {
+			t.Errorf("Unexpected value: %v for IdentityId", *gcfii.IdentityId)
+		}
+		if aws.StringValue(gcfii.Logins[loginProvider]) != "IDToken" {
+			t.Errorf("Unexpected logins: %v", aws.StringValueMap(gcfii.Logins))
+		}
+		return &cognitoidentity.GetCredentialsForIdentityOutput{
+			IdentityId: gcfii.IdentityId,
+			Credentials: &cognitoidentity.Credentials{
+				AccessKeyId:  aws.String("AccessKeyId"),
+				SecretKey:    aws.String("SecretKey"),
+				SessionToken: aws.String("SessionToken"),
+				Expiration:   &expiration,
+			},
+		}, nil
+	}
+
+	creds := credentials.NewCredentials(provider)
+	for i := 0; i < 2; i++ {
+		value, err := creds.Get()
+		if err != nil {
+			t.Fatalf("Get returned an error: %v", err)
+		}
+
+		if value.AccessKeyID != "AccessKeyId" || value.SecretAccessKey != "SecretKey" || value.SessionToken != "SessionToken" {
+			t.Errorf("Unexpected credentials: %v", value)
+		}
+	}
+
+	if provider.IdentityID() != "identityId" {
+		t.Errorf("Unexpected value: %v for IdentityID. Expected: %v", provider.IdentityID(), "identityId")
+	}
+
+	if getIDCalls != 1 {
+		t.Errorf("Unexpected number of GetId calls %d. Expected: %d", getIDCalls, 1)
+	}
+
+	if !provider.ExpiresAt().Equal(expiration.Add(-DefaultExpiryWindow)) {
+		t.Errorf("Unexpected expiry: %v. Expected: %v", provider.ExpiresAt(), expiration.Add(-DefaultExpiryWindow))
+	}
+
+	// Make the credentials expire to trigger a refresh without a new GetId call.
+	provider.SetExpiration(time.Now().Add(-1*time.Second), 0)
+	if _, err := creds.Get(); err != nil {
+		t.Errorf("Get returned an error: %v", err)
+	}
+
+	if getIDCalls != 1 {
+		t.Errorf("Unexpected number of GetId calls %d. Expected: %d", getIDCalls, 1)
+	}
+}
+
+func TestNewIdentityPoolProvider_InvalidUserpoolID(t *testing.T) {
+	if _, err := NewIdentityPoolProvider("eu-west-1:identityPoolId", "userpoolId", nil, nil); err == nil {
+		t.Error("Expected NewIdentityPoolProvider to return an error for an invalid user pool ID")
+	}
+}
+
+// Mock and helper functions
+type mockCognitoIdentity struct {
+	cognitoidentityiface.CognitoIdentityAPI
+	getIDHandler                     func(*cognitoidentity.GetIdInput) (*cognitoidentity.GetIdOutput, error)
+	getCredentialsForIdentityHandler func(*cognitoidentity.GetCredentialsForIdentityInput) (*cognitoidentity.GetCredentialsForIdentityOutput, error)
+}
+
+func (mci *mockCognitoIdentity) GetIdWithContext(ctx aws.Context, gii *cognitoidentity.GetIdInput, opts ...request.Option) (*cognitoidentity.GetIdOutput, error) {
+	return mci.getIDHandler(gii)
+}
+
+func (mci *mockCognitoIdentity) GetCredentialsForIdentityWithContext(ctx aws.Context, gcfii *cognitoidentity.GetCredentialsForIdentityInput, opts ...request.Option) (*cognitoidentity.GetCredentialsForIdentityOutput, error) {
+	return mci.getCredentialsForIdentityHandler(gcfii)
+}
+
+func getIdentityPoolProvider(mock cognitoidentityiface.CognitoIdentityAPI) *IdentityPoolProvider {
+	return &IdentityPoolProvider{
+		identityPoolID: "eu-west-1:identityPoolId",
+		loginProvider:  "cognito-idp.eu-west-1.amazonaws.com/eu-west-1_userpoolId",
+		source:         StaticTokenSource(&Token{IDToken: "IDToken"}),
+		identity:       mock,
+	}
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+)
+
+type logRecord struct {
+	level string
+	msg   string
+	args  []interface{}
+}
+
+type recordingLogger struct {
+	records []logRecord
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.record("DEBUG", msg, args) }
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.record("INFO", msg, args) }
+func (l *recordingLogger) Warn(msg string, args ...interface{})  { l.record("WARN", msg, args) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.record("ERROR", msg, args) }
+
+func (l *recordingLogger) record(level, msg string, args []interface{}) {
+	l.records = append(l.records, logRecord{level: level, msg: msg, args: args})
+}
+
+func (l *recordingLogger) String() string {
+	var sb strings.Builder
+	for _, r := range l.records {
+		fmt.Fprintf(&sb, "%s %s %v\n", r.level, r.msg, r.args)
+	}
+	return sb.String()
+}
+
+func TestTokenSource_Logger(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getTokenSource(cognitoMock)
+
+	logger := &recordingLogger{}
+	ts.config.Logger = logger
+
+	cognitoMock.respondToAuthChallengeHandler = func(*cip.RespondToAuthChallengeInput) (*cip.RespondToAuthChallengeOutput, error) {
+		return &cip.RespondToAuthChallengeOutput{
+			ChallengeName: aws.String("CUSTOM_CHALLENGE"),
+		}, nil
+	}
+
+	if _, err := ts.GetToken(); err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	out := logger.String()
+	for _, expected := range []string{"authenticating", "PASSWORD_VERIFIER", "unsupported auth challenge", "CUSTOM_CHALLENGE", "error authenticating"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected log to contain %q. Got:\n%s", expected, out)
+		}
+	}
+
+	if strings.Contains(out, ts.config.Password) {
+		t.Errorf("Log contains password:\n%s", out)
+	}
+}
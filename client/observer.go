@@ -0,0 +1,89 @@
+package client
+
+import (
+	"expvar"
+	"time"
+)
+
+// EventType identifies an authentication event observed by an Observer.
+type EventType string
+
+// Event types reported to an Observer.
+const (
+	// EventAuthenticateStart is reported when a TokenSource starts authenticating with username and password.
+	EventAuthenticateStart EventType = "authenticate_start"
+	// EventAuthenticateDone is reported when authentication has finished, successfully or not.
+	EventAuthenticateDone EventType = "authenticate_done"
+	// EventChallenge is reported when an auth challenge has been answered. Challenge holds the challenge name.
+	EventChallenge EventType = "challenge"
+	// EventRefresh is reported when a refresh with the refresh token has finished, successfully or not.
+	EventRefresh EventType = "refresh"
+	// EventCacheHit is reported when a valid cached token is returned without calling Cognito.
+	EventCacheHit EventType = "cache_hit"
+)
+
+// Event describes an authentication event. It never contains tokens, passwords or other secrets.
+type Event struct {
+	Type EventType
+	// Challenge is the name of the challenge for EventChallenge.
+	Challenge string
+	// Duration is the time spent calling Cognito for EventAuthenticateDone, EventChallenge and EventRefresh.
+	Duration time.Duration
+	// Err is the error, if any, for EventAuthenticateDone, EventChallenge and EventRefresh.
+	Err error
+}
+
+// Observer receives authentication events from a TokenSource. Observe is called synchronously while the TokenSource
+// is locked, so it should return quickly and must not call the TokenSource.
+type Observer interface {
+	Observe(Event)
+}
+
+// ObserverFunc is an adapter to allow the use of ordinary functions as Observers.
+type ObserverFunc func(Event)
+
+// Observe calls f(e).
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+// ExpvarObserver is an Observer counting events and accumulating durations in an expvar.Map. The keys are the event
+// type, "<type>_error" for events with an error, "<type>_duration_ns" with the total duration, and
+// "challenge_<name>" per challenge name.
+type ExpvarObserver struct {
+	m *expvar.Map
+}
+
+// NewExpvarObserver returns an ExpvarObserver publishing its counters as an expvar.Map with the given name. Like
+// expvar.NewMap it panics if the name is already registered.
+func NewExpvarObserver(name string) *ExpvarObserver {
+	return &ExpvarObserver{m: expvar.NewMap(name)}
+}
+
+// Map returns the map holding the counters.
+func (o *ExpvarObserver) Map() *expvar.Map {
+	return o.m
+}
+
+// Observe implements Observer.
+func (o *ExpvarObserver) Observe(e Event) {
+	o.m.Add(string(e.Type), 1)
+
+	if e.Err != nil {
+		o.m.Add(string(e.Type)+"_error", 1)
+	}
+
+	if e.Duration > 0 {
+		o.m.Add(string(e.Type)+"_duration_ns", int64(e.Duration))
+	}
+
+	if e.Challenge != "" {
+		o.m.Add(string(e.Type)+"_"+e.Challenge, 1)
+	}
+}
+
+func (ts *TokenSource) observe(e Event) {
+	if ts.config.Observer != nil {
+		ts.config.Observer.Observe(e)
+	}
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+)
+
+func TestTokenSource_Observer(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getTokenSource(cognitoMock)
+
+	var events []Event
+	ts.config.Observer = ObserverFunc(func(e Event) {
+		events = append(events, e)
+	})
+
+	cognitoMock.respondToAuthChallengeHandler = func(*cip.RespondToAuthChallengeInput) (*cip.RespondToAuthChallengeOutput, error) {
+		return &cip.RespondToAuthChallengeOutput{
+			AuthenticationResult: &cip.AuthenticationResultType{
+				AccessToken:  aws.String("AccessToken"),
+				IdToken:      aws.String("IDToken"),
+				RefreshToken: aws.String("RefreshToken"),
+				ExpiresIn:    aws.Int64(3600),
+				TokenType:    aws.String("Bearer"),
+			},
+		}, nil
+	}
+
+	if _, err := ts.GetToken(); err != nil {
+		t.Fatalf("GetToken returned an error: %v", err)
+	}
+	if _, err := ts.GetToken(); err != nil {
+		t.Fatalf("GetToken returned an error: %v", err)
+	}
+
+	ts.tkn.Expiration = time.Now().Add(-1 * time.Minute)
+	if _, err := ts.GetToken(); err != nil {
+		t.Fatalf("GetToken returned an error: %v", err)
+	}
+
+	expected := []Event{
+		{Type: EventAuthenticateStart},
+		{Type: EventChallenge, Challenge: "PASSWORD_VERIFIER"},
+		{Type: EventAuthenticateDone},
+		{Type: EventCacheHit},
+		{Type: EventRefresh},
+	}
+
+	if len(events) != len(expected) {
+		t.Fatalf("Unexpected number of events: %d. Expected: %d", len(events), len(expected))
+	}
+
+	for i, e := range events {
+		if e.Type != expected[i].Type || e.Challenge != expected[i].Challenge {
+			t.Errorf("Unexpected event %d: %s %q. Expected: %s %q", i, e.Type, e.Challenge, expected[i].Type, expected[i].Challenge)
+		}
+		if e.Err != nil {
+			t.Errorf("Unexpected error in event %d: %v", i, e.Err)
+		}
+	}
+}
+
+func TestTokenSource_Observer_Error(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getTokenSource(cognitoMock)
+
+	var events []Event
+	ts.config.Observer = ObserverFunc(func(e Event) {
+		events = append(events, e)
+	})
+
+	cognitoMock.respondToAuthChallengeHandler = func(*cip.RespondToAuthChallengeInput) (*cip.RespondToAuthChallengeOutput, error) {
+		return nil, errors.New("NotAuthorizedException")
+	}
+
+	if _, err := ts.GetToken(); err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Unexpected number of events: %d. Expected: %d", len(events), 3)
+	}
+
+	if events[1].Type != EventChallenge || events[1].Err == nil {
+		t.Errorf("Expected challenge event with error. Got: %+v", events[1])
+	}
+
+	if events[2].Type != EventAuthenticateDone || events[2].Err == nil {
+		t.Errorf("Expected authenticate done event with error. Got: %+v", events[2])
+	}
+}
+
+func TestExpvarObserver(t *testing.T) {
+	o := NewExpvarObserver("cognito_test_observer")
+
+	o.Observe(Event{Type: EventAuthenticateStart})
+	o.Observe(Event{Type: EventChallenge, Challenge: "PASSWORD_VERIFIER", Duration: time.Millisecond})
+	o.Observe(Event{Type: EventAuthenticateDone, Duration: 2 * time.Millisecond, Err: errors.New("error")})
+
+	tests := map[string]string{
+		"authenticate_start":            "1",
+		"challenge":                     "1",
+		"challenge_PASSWORD_VERIFIER":   "1",
+		"challenge_duration_ns":         "1000000",
+		"authenticate_done":             "1",
+		"authenticate_done_error":       "1",
+		"authenticate_done_duration_ns": "2000000",
+	}
+
+	for key, expected := range tests {
+		v := o.Map().Get(key)
+		if v == nil {
+			t.Errorf("Missing key %s", key)
+			continue
+		}
+		if v.String() != expected {
+			t.Errorf("Unexpected value: %s for %s. Expected: %s", v.String(), key, expected)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// TokenProvider supplies tokens to Transport and PerRPCCredentials. TokenSource implements it by authenticating with
+// Cognito, but any source of tokens can be used.
+type TokenProvider interface {
+	// Token returns a valid Token or an error. Implementations should give up when ctx is done.
+	Token(ctx context.Context) (*Token, error)
+}
+
+// StaticTokenSource returns a TokenProvider which always returns a copy of t. It never refreshes the Token, so it is
+// mostly useful for tests and for tokens obtained elsewhere, like the Cognito hosted UI. If t is nil the TokenProvider
+// returns an error.
+func StaticTokenSource(t *Token) TokenProvider {
+	if t == nil {
+		return staticTokenSource{}
+	}
+
+	// The Token is copied, so later changes by the caller are not seen by concurrent users of the TokenProvider.
+	c := *t
+	return staticTokenSource{t: &c}
+}
+
+type staticTokenSource struct {
+	t *Token
+}
+
+func (s staticTokenSource) Token(ctx context.Context) (*Token, error) {
+	if s.t == nil {
+		return nil, errors.New("error getting token: StaticTokenSource has no token")
+	}
+
+	t := *s.t
+	return &t, nil
+}
+
+// ReuseTokenSource returns a TokenProvider which returns t until it expires and then gets a new Token from src, which
+// is in turn cached until it expires. t may be nil. A Token with a zero Expiration is treated as never expiring. Like
+// Token.Valid, expiry is checked with the Clock of the TokenSource which returned the Token.
+func ReuseTokenSource(t *Token, src TokenProvider) TokenProvider {
+	if rts, ok := src.(*reuseTokenSource); ok {
+		if t == nil {
+			return rts
+		}
+		src = rts.src
+	}
+
+	return &reuseTokenSource{
+		t:   t,
+		src: src,
+	}
+}
+
+type reuseTokenSource struct {
+	src TokenProvider
+
+	mu sync.Mutex // guards t
+	t  *Token
+}
+
+func (s *reuseTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.t.Valid() {
+		t, err := s.src.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.t = t
+	}
+
+	// Callers get a copy, so they cannot modify the cached Token.
+	t := *s.t
+	return &t, nil
+}
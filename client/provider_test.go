@@ -0,0 +1,166 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStaticTokenSource(t *testing.T) {
+	token := &Token{IDToken: "IDToken"}
+	src := StaticTokenSource(token)
+
+	tkn, err := src.Token(context.Background())
+	if err != nil {
+		t.Errorf("Token returned an error: %v", err)
+	}
+
+	if tkn == token || *tkn != *token {
+		t.Error("StaticTokenSource did not return a copy of the Token")
+	}
+
+	tkn.IDToken = "changed"
+	if tkn, _ := src.Token(context.Background()); tkn.IDToken != "IDToken" {
+		t.Error("Changing a returned Token changed the Token of the StaticTokenSource")
+	}
+}
+
+func TestStaticTokenSource_Nil(t *testing.T) {
+	if _, err := StaticTokenSource(nil).Token(context.Background()); err == nil {
+		t.Error("Expected an error from a StaticTokenSource without a Token")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Request was sent without a Token")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{Source: StaticTokenSource(nil)}}
+	if _, err := client.Get(server.URL); err == nil {
+		t.Error("Expected an error from a Transport with a StaticTokenSource without a Token")
+	}
+}
+
+func TestReuseTokenSource(t *testing.T) {
+	src := &countingTokenProvider{expiresIn: time.Hour}
+	rts := ReuseTokenSource(nil, src)
+
+	for i := 0; i < 3; i++ {
+		if _, err := rts.Token(context.Background()); err != nil {
+			t.Errorf("Token returned an error: %v", err)
+		}
+	}
+
+	if src.calls != 1 {
+		t.Errorf("Unexpected number of calls to underlying source %d. Expected: %d", src.calls, 1)
+	}
+}
+
+func TestReuseTokenSource_Expired(t *testing.T) {
+	src := &countingTokenProvider{expiresIn: -time.Minute}
+	expired := &Token{IDToken: "expired", Expiration: time.Now().Add(-time.Minute)}
+	rts := ReuseTokenSource(expired, src)
+
+	tkn, err := rts.Token(context.Background())
+	if err != nil {
+		t.Errorf("Token returned an error: %v", err)
+	}
+
+	if tkn.IDToken == "expired" {
+		t.Error("ReuseTokenSource returned an expired Token")
+	}
+
+	if _, err := rts.Token(context.Background()); err != nil {
+		t.Errorf("Token returned an error: %v", err)
+	}
+
+	if src.calls != 2 {
+		t.Errorf("Unexpected number of calls to underlying source %d. Expected: %d", src.calls, 2)
+	}
+}
+
+func TestReuseTokenSource_Copy(t *testing.T) {
+	rts := ReuseTokenSource(nil, &countingTokenProvider{expiresIn: time.Hour})
+
+	tkn, err := rts.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token returned an error: %v", err)
+	}
+	tkn.IDToken = "modified"
+
+	if tkn, _ = rts.Token(context.Background()); tkn.IDToken != "IDToken" {
+		t.Errorf("Unexpected value: %v for IDToken. Expected: %v", tkn.IDToken, "IDToken")
+	}
+}
+
+// A Token without Expiration never expires, both in a TokenSource and in a ReuseTokenSource.
+func TestZeroExpiration(t *testing.T) {
+	ts := getTokenSource(nil)
+	ts.SetToken(&Token{AccessToken: "AccessToken", IDToken: "IDToken"})
+
+	if _, err := ts.Token(context.Background()); err != nil {
+		t.Errorf("TokenSource did not use the Token without Expiration: %v", err)
+	}
+
+	src := &countingTokenProvider{}
+	rts := ReuseTokenSource(&Token{IDToken: "IDToken"}, src)
+
+	if _, err := rts.Token(context.Background()); err != nil {
+		t.Errorf("Token returned an error: %v", err)
+	}
+
+	if src.calls != 0 {
+		t.Error("ReuseTokenSource replaced the Token without Expiration")
+	}
+}
+
+func TestTokenSource_Token_CanceledContext(t *testing.T) {
+	ts := getTokenSource(&mockCognito{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ts.Token(ctx); err == nil {
+		t.Error("Expected Token to return an error for a canceled context")
+	}
+}
+
+func TestConfig_Client_TokenProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "IDToken" {
+			t.Errorf("Unexpected value: %v for Authorization header. Expected: %v", auth, "IDToken")
+		}
+	}))
+	defer server.Close()
+
+	conf := &Config{
+		TokenProvider: StaticTokenSource(&Token{IDToken: "IDToken"}),
+	}
+
+	client, err := conf.Client()
+	if err != nil {
+		t.Fatalf("Client returned an error: %v", err)
+	}
+
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	res.Body.Close()
+}
+
+// countingTokenProvider returns a new Token expiring after expiresIn on every call.
+type countingTokenProvider struct {
+	expiresIn time.Duration
+	calls     int
+}
+
+func (p *countingTokenProvider) Token(ctx context.Context) (*Token, error) {
+	p.calls++
+	return &Token{
+		IDToken:    "IDToken",
+		Expiration: time.Now().Add(p.expiresIn),
+	}, nil
+}
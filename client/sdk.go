@@ -0,0 +1,82 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsclient "github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/corehandlers"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// sdkConfigProvider implements awsclient.ConfigProvider, configuring the aws-sdk-go service clients used by this
+// package without an aws-sdk-go session. The Cognito operations called are all unauthenticated, so the credential chain
+// and shared config files loaded by a session are not needed.
+type sdkConfigProvider struct {
+	config *aws.Config
+}
+
+// newSDKConfigProvider returns a sdkConfigProvider using awsConfig, which may be nil. region is used if awsConfig has
+// no region.
+func newSDKConfigProvider(awsConfig *aws.Config, region string) sdkConfigProvider {
+	conf := aws.NewConfig().
+		WithRegion(region).
+		WithHTTPClient(http.DefaultClient).
+		WithMaxRetries(aws.UseServiceDefaultRetries).
+		WithLogger(aws.NewDefaultLogger()).
+		WithLogLevel(aws.LogOff).
+		WithEndpointResolver(endpoints.DefaultResolver())
+	conf.MergeIn(awsConfig)
+	conf.Credentials = credentials.AnonymousCredentials
+
+	return sdkConfigProvider{config: conf}
+}
+
+// ClientConfig implements awsclient.ConfigProvider. It resolves the endpoint the same way as an aws-sdk-go session.
+func (p sdkConfigProvider) ClientConfig(serviceName string, cfgs ...*aws.Config) awsclient.Config {
+	conf := p.config.Copy(cfgs...)
+	conf.Credentials = credentials.AnonymousCredentials
+
+	var resolved endpoints.ResolvedEndpoint
+	region := aws.StringValue(conf.Region)
+	if endpoint := aws.StringValue(conf.Endpoint); endpoint != "" {
+		resolved.URL = endpoints.AddScheme(endpoint, aws.BoolValue(conf.DisableSSL))
+		resolved.SigningRegion = region
+	} else {
+		// Errors are left to surface when sending requests to the empty endpoint, like with a session.
+		resolved, _ = conf.EndpointResolver.EndpointFor(serviceName, region, func(opt *endpoints.Options) {
+			opt.DisableSSL = aws.BoolValue(conf.DisableSSL)
+			opt.UseDualStack = aws.BoolValue(conf.UseDualStack)
+			opt.ResolveUnknownService = true
+		})
+	}
+
+	return awsclient.Config{
+		Config:             conf,
+		Handlers:           sdkHandlers(),
+		Endpoint:           resolved.URL,
+		SigningRegion:      resolved.SigningRegion,
+		SigningNameDerived: resolved.SigningNameDerived,
+		SigningName:        resolved.SigningName,
+	}
+}
+
+// sdkHandlers returns the request handlers of an aws-sdk-go session, the same as defaults.Handlers.
+func sdkHandlers() request.Handlers {
+	var handlers request.Handlers
+
+	handlers.Validate.PushBackNamed(corehandlers.ValidateEndpointHandler)
+	handlers.Validate.AfterEachFn = request.HandlerListStopOnError
+	handlers.Build.PushBackNamed(corehandlers.SDKVersionUserAgentHandler)
+	handlers.Build.PushBackNamed(corehandlers.AddHostExecEnvUserAgentHander)
+	handlers.Build.AfterEachFn = request.HandlerListStopOnError
+	handlers.Sign.PushBackNamed(corehandlers.BuildContentLengthHandler)
+	handlers.Send.PushBackNamed(corehandlers.ValidateReqSigHandler)
+	handlers.Send.PushBackNamed(corehandlers.SendHandler)
+	handlers.AfterRetry.PushBackNamed(corehandlers.AfterRetryHandler)
+	handlers.ValidateResponse.PushBackNamed(corehandlers.ValidateResponseHandler)
+
+	return handlers
+}
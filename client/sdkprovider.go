@@ -0,0 +1,291 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go/service/cognitoidentityprovider/cognitoidentityprovideriface"
+	"github.com/larwef/cognito/internal/idp"
+)
+
+const opRevokeToken = "RevokeToken"
+
+// revokeTokenInput and revokeTokenOutput describe the RevokeToken operation which is missing from the version of
+// aws-sdk-go in use.
+type revokeTokenInput struct {
+	_ struct{} `type:"structure"`
+
+	ClientId *string `min:"1" type:"string" required:"true"`
+
+	Token *string `type:"string" required:"true" sensitive:"true"`
+}
+
+type revokeTokenOutput struct {
+	_ struct{} `type:"structure"`
+}
+
+// sdkProvider implements identityProvider with the aws-sdk-go client, or any other CognitoIdentityProviderAPI given as
+// Config.IdentityProvider. It converts between the plain types used by TokenSource and the aws-sdk-go types.
+type sdkProvider struct {
+	api cognitoidentityprovideriface.CognitoIdentityProviderAPI
+}
+
+var _ identityProvider = sdkProvider{}
+
+func (p sdkProvider) InitiateAuth(ctx context.Context, input *idp.InitiateAuthInput) (*idp.AuthOutput, error) {
+	res, err := p.api.InitiateAuthWithContext(ctx, &cip.InitiateAuthInput{
+		AuthFlow:       aws.String(input.AuthFlow),
+		AuthParameters: aws.StringMap(input.AuthParameters),
+		ClientId:       aws.String(input.ClientID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &idp.AuthOutput{
+		AuthenticationResult: authenticationResult(res.AuthenticationResult),
+		ChallengeName:        aws.StringValue(res.ChallengeName),
+		ChallengeParameters:  aws.StringValueMap(res.ChallengeParameters),
+		Session:              aws.StringValue(res.Session),
+	}, nil
+}
+
+func (p sdkProvider) RespondToAuthChallenge(ctx context.Context, input *idp.RespondToAuthChallengeInput) (*idp.AuthOutput, error) {
+	res, err := p.api.RespondToAuthChallengeWithContext(ctx, &cip.RespondToAuthChallengeInput{
+		ChallengeName:      aws.String(input.ChallengeName),
+		ChallengeResponses: aws.StringMap(input.ChallengeResponses),
+		ClientId:           aws.String(input.ClientID),
+		Session:            optionalString(input.Session),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &idp.AuthOutput{
+		AuthenticationResult: authenticationResult(res.AuthenticationResult),
+		ChallengeName:        aws.StringValue(res.ChallengeName),
+		ChallengeParameters:  aws.StringValueMap(res.ChallengeParameters),
+		Session:              aws.StringValue(res.Session),
+	}, nil
+}
+
+func (p sdkProvider) ChangePassword(ctx context.Context, input *idp.ChangePasswordInput) error {
+	_, err := p.api.ChangePasswordWithContext(ctx, &cip.ChangePasswordInput{
+		AccessToken:      aws.String(input.AccessToken),
+		PreviousPassword: aws.String(input.PreviousPassword),
+		ProposedPassword: aws.String(input.ProposedPassword),
+	})
+
+	return err
+}
+
+func (p sdkProvider) GetUser(ctx context.Context, input *idp.AccessTokenInput) (*idp.GetUserOutput, error) {
+	res, err := p.api.GetUserWithContext(ctx, &cip.GetUserInput{AccessToken: aws.String(input.AccessToken)})
+	if err != nil {
+		return nil, err
+	}
+
+	return &idp.GetUserOutput{
+		Username:            aws.StringValue(res.Username),
+		UserAttributes:      attributes(res.UserAttributes),
+		PreferredMfaSetting: aws.StringValue(res.PreferredMfaSetting),
+		UserMFASettingList:  aws.StringValueSlice(res.UserMFASettingList),
+	}, nil
+}
+
+func (p sdkProvider) UpdateUserAttributes(ctx context.Context, input *idp.UpdateUserAttributesInput) error {
+	var userAttributes []*cip.AttributeType
+	for _, attribute := range input.UserAttributes {
+		userAttributes = append(userAttributes, &cip.AttributeType{
+			Name:  aws.String(attribute.Name),
+			Value: aws.String(attribute.Value),
+		})
+	}
+
+	_, err := p.api.UpdateUserAttributesWithContext(ctx, &cip.UpdateUserAttributesInput{
+		AccessToken:    aws.String(input.AccessToken),
+		UserAttributes: userAttributes,
+	})
+
+	return err
+}
+
+func (p sdkProvider) VerifyUserAttribute(ctx context.Context, input *idp.VerifyUserAttributeInput) error {
+	_, err := p.api.VerifyUserAttributeWithContext(ctx, &cip.VerifyUserAttributeInput{
+		AccessToken:   aws.String(input.AccessToken),
+		AttributeName: aws.String(input.AttributeName),
+		Code:          aws.String(input.Code),
+	})
+
+	return err
+}
+
+func (p sdkProvider) DeleteUserAttributes(ctx context.Context, input *idp.DeleteUserAttributesInput) error {
+	_, err := p.api.DeleteUserAttributesWithContext(ctx, &cip.DeleteUserAttributesInput{
+		AccessToken:        aws.String(input.AccessToken),
+		UserAttributeNames: aws.StringSlice(input.UserAttributeNames),
+	})
+
+	return err
+}
+
+func (p sdkProvider) SetUserMFAPreference(ctx context.Context, input *idp.SetUserMFAPreferenceInput) error {
+	params := &cip.SetUserMFAPreferenceInput{AccessToken: aws.String(input.AccessToken)}
+	if s := input.SMSMfaSettings; s != nil {
+		params.SMSMfaSettings = &cip.SMSMfaSettingsType{Enabled: aws.Bool(s.Enabled), PreferredMfa: aws.Bool(s.PreferredMfa)}
+	}
+	if s := input.SoftwareTokenMfaSettings; s != nil {
+		params.SoftwareTokenMfaSettings = &cip.SoftwareTokenMfaSettingsType{Enabled: aws.Bool(s.Enabled), PreferredMfa: aws.Bool(s.PreferredMfa)}
+	}
+
+	_, err := p.api.SetUserMFAPreferenceWithContext(ctx, params)
+	return err
+}
+
+func (p sdkProvider) GlobalSignOut(ctx context.Context, input *idp.AccessTokenInput) error {
+	_, err := p.api.GlobalSignOutWithContext(ctx, &cip.GlobalSignOutInput{AccessToken: aws.String(input.AccessToken)})
+	return err
+}
+
+func (p sdkProvider) ListDevices(ctx context.Context, input *idp.ListDevicesInput) (*idp.ListDevicesOutput, error) {
+	params := &cip.ListDevicesInput{
+		AccessToken:     aws.String(input.AccessToken),
+		PaginationToken: optionalString(input.PaginationToken),
+	}
+	if input.Limit > 0 {
+		params.Limit = aws.Int64(input.Limit)
+	}
+
+	res, err := p.api.ListDevicesWithContext(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &idp.ListDevicesOutput{PaginationToken: aws.StringValue(res.PaginationToken)}
+	for _, deviceType := range res.Devices {
+		output.Devices = append(output.Devices, *device(deviceType))
+	}
+
+	return output, nil
+}
+
+func (p sdkProvider) GetDevice(ctx context.Context, input *idp.DeviceInput) (*idp.GetDeviceOutput, error) {
+	res, err := p.api.GetDeviceWithContext(ctx, &cip.GetDeviceInput{
+		AccessToken: aws.String(input.AccessToken),
+		DeviceKey:   aws.String(input.DeviceKey),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := &idp.GetDeviceOutput{}
+	if res.Device != nil {
+		output.Device = device(res.Device)
+	}
+
+	return output, nil
+}
+
+func (p sdkProvider) UpdateDeviceStatus(ctx context.Context, input *idp.UpdateDeviceStatusInput) error {
+	_, err := p.api.UpdateDeviceStatusWithContext(ctx, &cip.UpdateDeviceStatusInput{
+		AccessToken:            aws.String(input.AccessToken),
+		DeviceKey:              aws.String(input.DeviceKey),
+		DeviceRememberedStatus: aws.String(input.DeviceRememberedStatus),
+	})
+
+	return err
+}
+
+func (p sdkProvider) ForgetDevice(ctx context.Context, input *idp.DeviceInput) error {
+	_, err := p.api.ForgetDeviceWithContext(ctx, &cip.ForgetDeviceInput{
+		AccessToken: aws.String(input.AccessToken),
+		DeviceKey:   aws.String(input.DeviceKey),
+	})
+
+	return err
+}
+
+// RevokeToken calls RevokeToken on a TokenRevoker, or sends the request with the aws-sdk-go client, which has no method
+// for it.
+func (p sdkProvider) RevokeToken(ctx context.Context, input *idp.RevokeTokenInput) error {
+	switch api := p.api.(type) {
+	case TokenRevoker:
+		return api.RevokeToken(ctx, input.ClientID, input.Token)
+	case *cip.CognitoIdentityProvider:
+		op := &request.Operation{
+			Name:       opRevokeToken,
+			HTTPMethod: "POST",
+			HTTPPath:   "/",
+		}
+		params := &revokeTokenInput{
+			ClientId: aws.String(input.ClientID),
+			Token:    aws.String(input.Token),
+		}
+		req := api.NewRequest(op, params, &revokeTokenOutput{})
+		// RevokeToken is authorized by the client ID and the token, and must not be signed with AWS credentials.
+		req.Config.Credentials = credentials.AnonymousCredentials
+		req.SetContext(ctx)
+		return req.Send()
+	default:
+		return errors.New("identity provider does not support RevokeToken")
+	}
+}
+
+func authenticationResult(res *cip.AuthenticationResultType) *idp.AuthenticationResult {
+	if res == nil {
+		return nil
+	}
+
+	return &idp.AuthenticationResult{
+		AccessToken:  aws.StringValue(res.AccessToken),
+		ExpiresIn:    aws.Int64Value(res.ExpiresIn),
+		IDToken:      aws.StringValue(res.IdToken),
+		RefreshToken: aws.StringValue(res.RefreshToken),
+		TokenType:    aws.StringValue(res.TokenType),
+	}
+}
+
+func attributes(attributeTypes []*cip.AttributeType) []idp.Attribute {
+	var attributes []idp.Attribute
+	for _, attribute := range attributeTypes {
+		attributes = append(attributes, idp.Attribute{
+			Name:  aws.StringValue(attribute.Name),
+			Value: aws.StringValue(attribute.Value),
+		})
+	}
+
+	return attributes
+}
+
+func device(deviceType *cip.DeviceType) *idp.Device {
+	return &idp.Device{
+		DeviceKey:                   aws.StringValue(deviceType.DeviceKey),
+		DeviceAttributes:            attributes(deviceType.DeviceAttributes),
+		DeviceCreateDate:            epochSeconds(deviceType.DeviceCreateDate),
+		DeviceLastAuthenticatedDate: epochSeconds(deviceType.DeviceLastAuthenticatedDate),
+		DeviceLastModifiedDate:      epochSeconds(deviceType.DeviceLastModifiedDate),
+	}
+}
+
+// optionalString returns nil for an empty string, the way aws-sdk-go leaves out missing fields.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return aws.String(s)
+}
+
+// epochSeconds returns t as seconds since the Unix epoch, like Cognito sends dates. A missing time is 0.
+func epochSeconds(t *time.Time) float64 {
+	if t == nil || t.IsZero() {
+		return 0
+	}
+
+	return float64(t.Unix()) + float64(t.Nanosecond())/float64(time.Second)
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+	"github.com/larwef/cognito/internal/idp"
+)
+
+func TestNewTokenSource_Lightweight(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		switch r.Header.Get("X-Amz-Target") {
+		case "AWSCognitoIdentityProviderService.ListDevices":
+			w.Write([]byte(`{"Devices":[{"DeviceKey":"deviceKey","DeviceAttributes":[{"Name":"device_name","Value":"laptop"}],"DeviceCreateDate":1.5566688E9}]}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"NotAuthorizedException","message":"Access Token has been revoked"}`))
+		}
+	}))
+	defer server.Close()
+
+	ts, err := NewTokenSource(&Config{
+		UserpoolID:  "eu-west-1_userpoolId",
+		ClientID:    "clientId",
+		Lightweight: true,
+		Endpoint:    server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewTokenSource returned an error: %v", err)
+	}
+
+	if _, ok := ts.identityProvider.(*idp.Client); !ok {
+		t.Fatalf("Expected the lightweight client but got: %T", ts.identityProvider)
+	}
+	ts.SetToken(&Token{AccessToken: "AccessToken", Expiration: time.Now().Add(time.Hour)})
+
+	devices, err := ts.ListDevices(context.Background())
+	if err != nil {
+		t.Fatalf("ListDevices returned an error: %v", err)
+	}
+
+	if len(devices) != 1 || devices[0].Key != "deviceKey" || devices[0].Attributes["device_name"] != "laptop" {
+		t.Fatalf("Unexpected devices: %+v", devices)
+	}
+
+	if expected := time.Unix(1556668800, 0); !devices[0].CreateDate.Equal(expected) {
+		t.Errorf("Unexpected value: %v for CreateDate. Expected: %v", devices[0].CreateDate, expected)
+	}
+
+	if !devices[0].LastModifiedDate.IsZero() {
+		t.Errorf("Unexpected value: %v for LastModifiedDate. Expected zero time", devices[0].LastModifiedDate)
+	}
+
+	if _, err := ts.GetUser(context.Background()); err == nil || !strings.Contains(err.Error(), cip.ErrCodeNotAuthorizedException) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestNewTokenSource_SDKClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authorization := r.Header.Get("Authorization"); authorization != "" {
+			t.Errorf("Request was signed: %s", authorization)
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Write([]byte(`{"AuthenticationResult":{"AccessToken":"refreshedAccessToken","ExpiresIn":3600,"IdToken":"refreshedIdToken","TokenType":"Bearer"}}`))
+	}))
+	defer server.Close()
+
+	ts, err := NewTokenSource(&Config{
+		UserpoolID: "eu-west-1_userpoolId",
+		ClientID:   "clientId",
+		AWSConfig:  &aws.Config{Endpoint: aws.String(server.URL)},
+	})
+	if err != nil {
+		t.Fatalf("NewTokenSource returned an error: %v", err)
+	}
+
+	if p, ok := ts.identityProvider.(sdkProvider); !ok {
+		t.Fatalf("Expected sdkProvider but got: %T", ts.identityProvider)
+	} else if _, ok := p.api.(*cip.CognitoIdentityProvider); !ok {
+		t.Fatalf("Expected the aws-sdk-go client but got: %T", p.api)
+	}
+	ts.tkn.RefreshToken = "RefreshToken"
+
+	tkn, err := ts.GetToken()
+	if err != nil {
+		t.Fatalf("GetToken returned an error: %v", err)
+	}
+
+	if tkn.AccessToken != "refreshedAccessToken" {
+		t.Error("AccessToken has unexpected value")
+	}
+}
+
+func TestEpochSeconds(t *testing.T) {
+	for _, date := range []time.Time{time.Unix(1556668800, 0), time.Unix(1556668800, int64(250*time.Millisecond)), time.Unix(1556668800, int64(123*time.Millisecond)), {}} {
+		if converted := epochTime(epochSeconds(&date)); !converted.Equal(date) {
+			t.Errorf("Unexpected value: %v after converting %v", converted, date)
+		}
+	}
+
+	if seconds := epochSeconds(nil); seconds != 0 {
+		t.Errorf("Unexpected value: %v for a missing date. Expected 0", seconds)
+	}
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/larwef/cognito/internal/idp"
+)
+
+var _ io.Closer = (*TokenSource)(nil)
+
+// TokenRevoker is implemented by identity providers supporting the RevokeToken operation, which is missing from
+// CognitoIdentityProviderAPI. An IdentityProvider given in the Config must implement it for SignOut to revoke the
+// refresh token.
+type TokenRevoker interface {
+	RevokeToken(ctx context.Context, clientID, token string) error
+}
+
+// SignOut ends the current session. If global is true all tokens issued to the user are invalidated on all devices
+// using GlobalSignOut, which requires an unexpired access token. If the access token has expired, the refresh token is
+// revoked instead, ending only this session, and an error tells that the global sign out was not done. If global is
+// false only the refresh token held by the TokenSource is revoked. The calls to Cognito are canceled when ctx is done.
+//
+// The TokenSource forgets its tokens once the session is ended. If the call to Cognito fails the tokens are kept, so
+// SignOut can be retried.
+func (ts *TokenSource) SignOut(ctx context.Context, global bool) error {
+	if err := ts.lockContext(ctx); err != nil {
+		return err
+	}
+	tkn := ts.tkn
+	ts.unlock()
+
+	if global && tkn.AccessToken != "" && tkn.validAt(ts.now()) {
+		ts.logger().Debug("signing out globally", "username", ts.config.Username)
+		if err := ts.identityProvider.GlobalSignOut(ctx, &idp.AccessTokenInput{AccessToken: tkn.AccessToken}); err != nil {
+			ts.logger().Error("error signing out globally", "error", err)
+			return fmt.Errorf("error signing out globally: %v", err)
+		}
+
+		ts.forgetToken()
+		return nil
+	}
+
+	if tkn.RefreshToken == "" {
+		// Without a refresh token there is no session left to end at Cognito.
+		ts.forgetToken()
+		return nil
+	}
+
+	// The TokenSource does not refresh only to sign out globally. Revoking the refresh token still ends the session.
+	ts.logger().Debug("revoking refresh token", "username", ts.config.Username)
+	params := &idp.RevokeTokenInput{
+		ClientID: ts.config.ClientID,
+		Token:    tkn.RefreshToken,
+	}
+	if err := ts.identityProvider.RevokeToken(ctx, params); err != nil {
+		ts.logger().Error("error revoking refresh token", "error", err)
+		return fmt.Errorf("error revoking refresh token: %v", err)
+	}
+	ts.forgetToken()
+
+	if global {
+		ts.logger().Warn("access token has expired, revoked the refresh token instead of signing out globally")
+		return errors.New("error signing out globally: access token has expired, only the refresh token was revoked")
+	}
+
+	return nil
+}
+
+// Close implements io.Closer. The refresh token is revoked if RevokeOnClose is set in the Config, otherwise the tokens
+// are only forgotten. If revoking fails the tokens are kept, like with SignOut.
+func (ts *TokenSource) Close() error {
+	if ts.config.RevokeOnClose {
+		return ts.SignOut(context.Background(), false)
+	}
+
+	ts.forgetToken()
+	return nil
+}
+
+func (ts *TokenSource) forgetToken() {
+	ts.lock()
+	defer ts.unlock()
+	ts.tkn = Token{}
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+	"github.com/larwef/cognito/internal/idp"
+)
+
+func TestTokenSource_SignOut(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	cognitoMock.revokeTokenHandler = func(clientID, token string) error {
+		if token != "RefreshToken" {
+			t.Errorf("Unexpected value: %v for Token. Expected: %v", token, "RefreshToken")
+		}
+		if clientID != "clientId" {
+			t.Errorf("Unexpected value: %v for ClientId. Expected: %v", clientID, "clientId")
+		}
+		return nil
+	}
+
+	if err := ts.SignOut(context.Background(), false); err != nil {
+		t.Errorf("SignOut returned an error: %v", err)
+	}
+
+	if ts.tkn != (Token{}) {
+		t.Error("Token was not cleared after SignOut")
+	}
+}
+
+func TestTokenSource_SignOut_Global(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	cognitoMock.globalSignOutHandler = func(gsoi *cip.GlobalSignOutInput) (*cip.GlobalSignOutOutput, error) {
+		if *gsoi.AccessToken != "AccessToken" {
+			t.Errorf("Unexpected value: %v for AccessToken. Expected: %v", *gsoi.AccessToken, "AccessToken")
+		}
+		return &cip.GlobalSignOutOutput{}, nil
+	}
+
+	if err := ts.SignOut(context.Background(), true); err != nil {
+		t.Errorf("SignOut returned an error: %v", err)
+	}
+
+	if ts.tkn != (Token{}) {
+		t.Error("Token was not cleared after SignOut")
+	}
+}
+
+func TestTokenSource_SignOut_GlobalExpired(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+	ts.tkn.Expiration = time.Now().Add(-time.Minute)
+
+	cognitoMock.initiateAuthhandler = func(iai *cip.InitiateAuthInput) (*cip.InitiateAuthOutput, error) {
+		t.Error("SignOut refreshed the token")
+		return nil, errors.New("unexpected call")
+	}
+	cognitoMock.globalSignOutHandler = func(gsoi *cip.GlobalSignOutInput) (*cip.GlobalSignOutOutput, error) {
+		t.Error("SignOut called GlobalSignOut with an expired access token")
+		return &cip.GlobalSignOutOutput{}, nil
+	}
+	revoked := false
+	cognitoMock.revokeTokenHandler = func(clientID, token string) error {
+		revoked = token == "RefreshToken"
+		return nil
+	}
+
+	if err := ts.SignOut(context.Background(), true); err == nil {
+		t.Error("Expected an error signing out globally with an expired access token")
+	}
+
+	if !revoked {
+		t.Error("The refresh token was not revoked")
+	}
+
+	if ts.tkn != (Token{}) {
+		t.Error("Token was not cleared after the refresh token was revoked")
+	}
+
+	// Without a refresh token there is nothing to revoke.
+	ts.SetToken(&Token{AccessToken: "AccessToken", Expiration: time.Now().Add(-time.Minute)})
+	revoked = false
+	if err := ts.SignOut(context.Background(), true); err != nil {
+		t.Errorf("SignOut returned an error: %v", err)
+	}
+
+	if revoked || ts.tkn != (Token{}) {
+		t.Error("Expected the token to be forgotten without calling Cognito")
+	}
+}
+
+func TestTokenSource_SignOut_Error(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	cognitoMock.revokeTokenHandler = func(clientID, token string) error {
+		return errors.New("revoke failed")
+	}
+	cognitoMock.globalSignOutHandler = func(gsoi *cip.GlobalSignOutInput) (*cip.GlobalSignOutOutput, error) {
+		return nil, errors.New("global sign out failed")
+	}
+
+	for _, global := range []bool{false, true} {
+		if err := ts.SignOut(context.Background(), global); err == nil {
+			t.Errorf("Expected an error from SignOut with global %v", global)
+		}
+
+		if ts.tkn.RefreshToken != "RefreshToken" {
+			t.Errorf("Refresh token was forgotten after a failed SignOut with global %v", global)
+		}
+	}
+}
+
+func TestTokenSource_Close(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	revoked := false
+	cognitoMock.revokeTokenHandler = func(clientID, token string) error {
+		revoked = true
+		return nil
+	}
+
+	if err := ts.Close(); err != nil {
+		t.Errorf("Close returned an error: %v", err)
+	}
+
+	if revoked {
+		t.Error("Close revoked the refresh token without RevokeOnClose set")
+	}
+
+	ts = getAuthenticatedTokenSource(cognitoMock)
+	ts.config.RevokeOnClose = true
+	if err := ts.Close(); err != nil {
+		t.Errorf("Close returned an error: %v", err)
+	}
+
+	if !revoked {
+		t.Error("Close did not revoke the refresh token with RevokeOnClose set")
+	}
+}
+
+func TestRevokeToken_SDKClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authorization := r.Header.Get("Authorization"); authorization != "" {
+			t.Errorf("RevokeToken request was signed: %s", authorization)
+		}
+		if target := r.Header.Get("X-Amz-Target"); target != "AWSCognitoIdentityProviderService.RevokeToken" {
+			t.Errorf("Unexpected X-Amz-Target: %s", target)
+		}
+
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Error decoding request body: %v", err)
+		}
+		if body["Token"] != "RefreshToken" || body["ClientId"] != "clientId" {
+			t.Errorf("Unexpected request body: %v", body)
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	// The default credential chain finds these credentials, which must not be used to sign the request.
+	for key, value := range map[string]string{
+		"AWS_ACCESS_KEY_ID":         "accessKeyID",
+		"AWS_SECRET_ACCESS_KEY":     "secretAccessKey",
+		"AWS_EC2_METADATA_DISABLED": "true",
+	} {
+		defer os.Setenv(key, os.Getenv(key))
+		os.Setenv(key, value)
+	}
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:   aws.String("eu-west-1"),
+		Endpoint: aws.String(server.URL),
+	})
+	if err != nil {
+		t.Fatalf("Error getting session: %v", err)
+	}
+
+	p := sdkProvider{api: cip.New(sess)}
+	if err := p.RevokeToken(context.Background(), &idp.RevokeTokenInput{ClientID: "clientId", Token: "RefreshToken"}); err != nil {
+		t.Errorf("RevokeToken returned an error: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+// DefaultSigningService is the service requests are signed for by SigV4Transport, unless configured otherwise. It is
+// the service name of API Gateway.
+const DefaultSigningService = "execute-api"
+
+// SigV4Transport is an http.RoundTripper that signs requests with AWS Signature Version 4, for APIs using IAM
+// authorization like API Gateway with AWS_IAM. Unlike Transport it does not add the Cognito token to the requests.
+//
+// SigV4Transport is a low-level mechanism. Most code will use the higher-level Config.IAMClient method instead.
+type SigV4Transport struct {
+	// Credentials used to sign requests, eg. from an IdentityPoolProvider.
+	Credentials *credentials.Credentials
+
+	// Service is the signing name of the service the requests are sent to. Defaults to DefaultSigningService.
+	Service string
+
+	// Region is the region of the service the requests are sent to.
+	Region string
+
+	// Base is the base RoundTripper used to make HTTP requests.
+	// If nil, http.DefaultTransport is used.
+	Base http.RoundTripper
+}
+
+// RoundTrip signs the request with credentials from Transport's Credentials. The request body is read into memory to
+// compute the payload hash.
+func (t *SigV4Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Credentials == nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, errors.New("cognito: SigV4Transport's Credentials is nil")
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("error reading request body: %v", err)
+	}
+
+	service := t.Service
+	if service == "" {
+		service = DefaultSigningService
+	}
+
+	req2 := cloneRequest(req) // per RoundTripper contract
+	req2.Header.Del("Authorization")
+
+	var seeker io.ReadSeeker
+	if body != nil {
+		seeker = bytes.NewReader(body)
+	}
+	if _, err := v4.NewSigner(t.Credentials).Sign(req2, seeker, service, t.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("error signing request: %v", err)
+	}
+
+	return t.base().RoundTrip(req2)
+}
+
+func (t *SigV4Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// readBody reads and closes the body of req. Returns nil if the request has no body.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer req.Body.Close()
+
+	return ioutil.ReadAll(req.Body)
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+func TestSigV4Transport_RoundTrip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AccessKeyId/") {
+			t.Errorf("Unexpected value: %v for Authorization header", auth)
+		}
+
+		if !strings.Contains(auth, "/eu-west-1/execute-api/aws4_request") {
+			t.Errorf("Authorization header has unexpected scope: %v", auth)
+		}
+
+		if token := r.Header.Get("X-Amz-Security-Token"); token != "SessionToken" {
+			t.Errorf("Unexpected value: %v for X-Amz-Security-Token header", token)
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("Error reading body: %v", err)
+		}
+
+		if string(body) != `{"key":"value"}` {
+			t.Errorf("Unexpected body: %s", body)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &SigV4Transport{
+			Credentials: credentials.NewStaticCredentials("AccessKeyId", "SecretKey", "SessionToken"),
+			Region:      "eu-west-1",
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"key":"value"}`))
+	if err != nil {
+		t.Fatalf("Error getting request: %v", err)
+	}
+	req.Header.Set("Authorization", "IDToken")
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do returned an error: %v", err)
+	}
+	res.Body.Close()
+
+	if req.Header.Get("Authorization") != "IDToken" {
+		t.Error("Original request was modified")
+	}
+}
+
+func TestSigV4Transport_RoundTrip_NilCredentials(t *testing.T) {
+	transport := &SigV4Transport{Region: "eu-west-1"}
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	if err != nil {
+		t.Fatalf("Error getting request: %v", err)
+	}
+
+	if _, err := transport.RoundTrip(req); err == nil {
+		t.Error("Expected RoundTrip to return an error")
+	}
+}
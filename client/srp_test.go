@@ -1,9 +1,14 @@
 package client
 
 import (
+	"bytes"
+	"crypto/rand"
 	"encoding/base64"
 	"math/big"
 	"testing"
+
+	"github.com/larwef/cognito/cognitotest"
+	srp6a "github.com/larwef/cognito/srp"
 )
 
 var srpTestTable = []struct {
@@ -82,12 +87,12 @@ func TestSrp(t *testing.T) {
 			t.Errorf("newSrp returned an error: %v", err)
 		}
 
-		if s.k.Text(16) != elem.k {
-			t.Errorf("k values not equal. Got: %v Expected: %v", s.k.Text(16), elem.k)
+		if k := srp6a.Cognito.K(); k.Text(16) != elem.k {
+			t.Errorf("k values not equal. Got: %v Expected: %v", k.Text(16), elem.k)
 		}
 
-		if s.xA.Text(16) != elem.xA {
-			t.Errorf("A values not equal. Got: %v Expected: %v", s.k.Text(16), elem.k)
+		if s.getA().Text(16) != elem.xA {
+			t.Errorf("A values not equal. Got: %v Expected: %v", s.getA().Text(16), elem.xA)
 		}
 
 		salt, _ := big.NewInt(0).SetString(elem.salt, 16)
@@ -104,3 +109,140 @@ func TestSrp(t *testing.T) {
 		}
 	}
 }
+
+func TestSrp_Server(t *testing.T) {
+	for _, elem := range srpTestTable {
+		a, _ := big.NewInt(0).SetString(elem.a, 16)
+		salt, _ := big.NewInt(0).SetString(elem.salt, 16)
+		secretBlock, _ := base64.StdEncoding.DecodeString(elem.secretBlock)
+
+		s, err := newSrp(a)
+		if err != nil {
+			t.Fatalf("newSrp returned an error: %v", err)
+		}
+
+		v := cognitotest.SRPVerifier(elem.userPoolName, elem.username, elem.password, salt)
+		server, err := cognitotest.NewSRPServer(elem.userPoolName, elem.username, salt, v, s.getA(), nil)
+		if err != nil {
+			t.Fatalf("NewSRPServer returned an error: %v", err)
+		}
+
+		signature, err := s.getSignature(elem.userPoolName, elem.username, elem.password, elem.timestamp, salt, server.B(), secretBlock)
+		if err != nil {
+			t.Errorf("getSignature returned an error: %v", err)
+		}
+
+		if err := server.VerifyClaim(secretBlock, elem.timestamp, signature); err != nil {
+			t.Errorf("VerifyClaim returned an error: %v", err)
+		}
+
+		signature, _ = s.getSignature(elem.userPoolName, elem.username, "wrong"+elem.password, elem.timestamp, salt, server.B(), secretBlock)
+		if err := server.VerifyClaim(secretBlock, elem.timestamp, signature); err != cognitotest.ErrInvalidClaim {
+			t.Errorf("Expected ErrInvalidClaim for wrong password. Got: %v", err)
+		}
+	}
+}
+
+func TestSrp_InvalidParameters(t *testing.T) {
+	elem := srpTestTable[0]
+	a, _ := big.NewInt(0).SetString(elem.a, 16)
+	salt, _ := big.NewInt(0).SetString(elem.salt, 16)
+	xB, _ := big.NewInt(0).SetString(elem.xB, 16)
+
+	s, err := newSrp(a)
+	if err != nil {
+		t.Fatalf("newSrp returned an error: %v", err)
+	}
+
+	xN := srp6a.Cognito.Group.N
+	for _, b := range []*big.Int{nil, big.NewInt(0), xN, big.NewInt(0).Add(xN, big.NewInt(1))} {
+		if _, err := s.getSignature(elem.userPoolName, elem.username, elem.password, elem.timestamp, salt, b, nil); err != srp6a.ErrInvalidPublicKey {
+			t.Errorf("Expected ErrInvalidPublicKey for B: %v. Got: %v", b, err)
+		}
+	}
+
+	if _, err := s.getSignature(elem.userPoolName, elem.username, elem.password, elem.timestamp, nil, xB, nil); err != errInvalidSalt {
+		t.Errorf("Expected errInvalidSalt. Got: %v", err)
+	}
+
+	for _, privateKey := range []*big.Int{nil, big.NewInt(0), big.NewInt(-1), xN} {
+		if _, err := newSrp(privateKey); err != srp6a.ErrInvalidPrivateKey {
+			t.Errorf("Expected ErrInvalidPrivateKey for private key: %v. Got: %v", privateKey, err)
+		}
+	}
+}
+
+func TestGeneratePrivateKey(t *testing.T) {
+	const nBits = 3072
+	random := bytes.Repeat([]byte{0x01}, 2*nBits/8)
+
+	a1, err := generatePrivateKey(bytes.NewReader(random))
+	if err != nil {
+		t.Fatalf("generatePrivateKey returned an error: %v", err)
+	}
+
+	a2, _ := generatePrivateKey(bytes.NewReader(random))
+	if a1.Cmp(a2) != 0 {
+		t.Error("Expected equal private keys from equal random sources")
+	}
+
+	if _, err := generatePrivateKey(bytes.NewReader(random[:10])); err == nil {
+		t.Error("Expected generatePrivateKey to return an error on short read")
+	}
+
+	if _, err := generatePrivateKey(bytes.NewReader(make([]byte, nBits/8))); err != srp6a.ErrInvalidPrivateKey {
+		t.Errorf("Expected ErrInvalidPrivateKey for zero private key. Got: %v", err)
+	}
+}
+
+func BenchmarkNewSrp(b *testing.B) {
+	a, _ := big.NewInt(0).SetString(srpTestTable[0].a, 16)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := newSrp(a); err != nil {
+			b.Fatalf("newSrp returned an error: %v", err)
+		}
+	}
+}
+
+func BenchmarkGetSignature(b *testing.B) {
+	elem := srpTestTable[0]
+	a, _ := big.NewInt(0).SetString(elem.a, 16)
+	salt, _ := big.NewInt(0).SetString(elem.salt, 16)
+	xB, _ := big.NewInt(0).SetString(elem.xB, 16)
+	secretBlock, _ := base64.StdEncoding.DecodeString(elem.secretBlock)
+	s, _ := newSrp(a)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.getSignature(elem.userPoolName, elem.username, elem.password, elem.timestamp, salt, xB, secretBlock); err != nil {
+			b.Fatalf("getSignature returned an error: %v", err)
+		}
+	}
+}
+
+func BenchmarkLogin(b *testing.B) {
+	elem := srpTestTable[0]
+	salt, _ := big.NewInt(0).SetString(elem.salt, 16)
+	xB, _ := big.NewInt(0).SetString(elem.xB, 16)
+	secretBlock, _ := base64.StdEncoding.DecodeString(elem.secretBlock)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		a, err := generatePrivateKey(rand.Reader)
+		if err != nil {
+			b.Fatalf("generatePrivateKey returned an error: %v", err)
+		}
+
+		s, err := newSrp(a)
+		if err != nil {
+			b.Fatalf("newSrp returned an error: %v", err)
+		}
+
+		if _, err := s.getSignature(elem.userPoolName, elem.username, elem.password, elem.timestamp, salt, xB, secretBlock); err != nil {
+			b.Fatalf("getSignature returned an error: %v", err)
+		}
+	}
+}
@@ -2,40 +2,237 @@ package client
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"net/http"
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
 	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
-	"github.com/aws/aws-sdk-go/service/cognitoidentityprovider/cognitoidentityprovideriface"
 	"github.com/google/uuid"
+	"github.com/larwef/cognito/internal/clock"
+	"github.com/larwef/cognito/internal/idp"
+	"github.com/larwef/cognito/internal/logging"
+	"github.com/larwef/cognito/verifier"
 )
 
 const metadataAuthorizationFieldName string = "authorization"
 
 const timestampFormat string = "Mon Jan 2 15:04:05 MST 2006"
 
-// Token holds the credentials received from Cognito
+// Token holds the credentials received from Cognito. Expiration is the earlier of the exp claims of the access and ID
+// tokens, so the Token is refreshed before either of them expires.
 type Token struct {
 	AccessToken  string
 	IDToken      string
 	RefreshToken string
 	TokenType    string
 	Expiration   time.Time
+
+	// clock is the Clock of the TokenSource which returned the Token. It is used by Valid and ExpiresIn.
+	clock Clock
+}
+
+// Valid reports whether t holds a token which has not expired. A Token without Expiration never expires. The time is
+// told by the Clock of the TokenSource which returned the Token, or the system clock.
+func (t *Token) Valid() bool {
+	if t == nil {
+		return false
+	}
+
+	return t.validAt(clock.Now(t.clock))
+}
+
+// validAt reports whether t holds a token which has not expired at now. It is the single rule for expiry used by
+// Token, TokenSource and ReuseTokenSource.
+func (t *Token) validAt(now time.Time) bool {
+	if t == nil || (t.AccessToken == "" && t.IDToken == "") {
+		return false
+	}
+
+	return t.Expiration.IsZero() || now.Before(t.Expiration)
+}
+
+// ExpiresIn returns the time until the Token expires. It is negative if the Token has expired and 0 if it has no
+// Expiration. Like Valid, it uses the Clock of the TokenSource which returned the Token.
+func (t *Token) ExpiresIn() time.Duration {
+	if t.Expiration.IsZero() {
+		return 0
+	}
+
+	return t.Expiration.Sub(clock.Now(t.clock))
+}
+
+// IDTokenClaims returns the claims of the ID token. The signature is not verified, use a verifier.JWTVerifier for that.
+func (t *Token) IDTokenClaims() (map[string]interface{}, error) {
+	return decodeClaims(t.IDToken)
+}
+
+// AccessTokenClaims returns the claims of the access token. The signature is not verified, use a verifier.JWTVerifier
+// for that.
+func (t *Token) AccessTokenClaims() (map[string]interface{}, error) {
+	return decodeClaims(t.AccessToken)
+}
+
+// AccessTokenExpiration returns the expiration time in the exp claim of the access token.
+func (t *Token) AccessTokenExpiration() (time.Time, error) {
+	return tokenExpiration(t.AccessToken)
+}
+
+// IDTokenExpiration returns the expiration time in the exp claim of the ID token.
+func (t *Token) IDTokenExpiration() (time.Time, error) {
+	return tokenExpiration(t.IDToken)
+}
+
+// claimsExpiration returns the earlier of the expiration times of the access and ID tokens. ok is false if neither
+// token has an exp claim.
+func (t *Token) claimsExpiration() (expiration time.Time, ok bool) {
+	for _, token := range []string{t.AccessToken, t.IDToken} {
+		exp, err := tokenExpiration(token)
+		if err != nil {
+			continue
+		}
+
+		if !ok || exp.Before(expiration) {
+			expiration = exp
+			ok = true
+		}
+	}
+
+	return expiration, ok
+}
+
+func tokenExpiration(token string) (time.Time, error) {
+	claims, err := decodeClaims(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return time.Time{}, errors.New("token has no exp claim")
+	}
+
+	return time.Unix(int64(exp), 0), nil
+}
+
+func decodeClaims(token string) (map[string]interface{}, error) {
+	if token == "" {
+		return nil, errors.New("token is empty")
+	}
+
+	jwt, err := verifier.ParseJWT(token)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing token: %v", err)
+	}
+
+	return jwt.Claims, nil
+}
+
+// String returns a description of the Token with the token values redacted, so Tokens can be printed and logged with
+// %v and %+v.
+func (t Token) String() string {
+	return fmt.Sprintf("{TokenType: %s, AccessToken: %s, IDToken: %s, RefreshToken: %s, Expiration: %s}",
+		t.TokenType, redact(t.AccessToken), redact(t.IDToken), redact(t.RefreshToken), t.Expiration.Format(time.RFC3339))
+}
+
+// GoString returns a Go syntax representation of the Token with the token values redacted, used by %#v.
+func (t Token) GoString() string {
+	return fmt.Sprintf("client.Token{AccessToken:%q, IDToken:%q, RefreshToken:%q, TokenType:%q, Expiration:%#v}",
+		redact(t.AccessToken), redact(t.IDToken), redact(t.RefreshToken), t.TokenType, t.Expiration)
+}
+
+func redact(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	return logging.Redacted
+}
+
+// tokenJSON is the JSON representation of a Token.
+type tokenJSON struct {
+	AccessToken  string    `json:"access_token,omitempty"`
+	IDToken      string    `json:"id_token,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	Expiration   time.Time `json:"expiration"`
+}
+
+// MarshalJSON implements json.Marshaler. Like String, the token values are redacted, so a Token can be included in
+// JSON output and logs. Use Persist to save a Token.
+func (t Token) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tokenJSON{
+		AccessToken:  redact(t.AccessToken),
+		IDToken:      redact(t.IDToken),
+		RefreshToken: redact(t.RefreshToken),
+		TokenType:    t.TokenType,
+		Expiration:   t.Expiration,
+	})
+}
+
+// Persist returns the Token as JSON including the token values, eg. for a credential cache. The result must be stored
+// as securely as the password. It is restored with json.Unmarshal.
+func (t Token) Persist() ([]byte, error) {
+	return json.Marshal(tokenJSON{
+		AccessToken:  t.AccessToken,
+		IDToken:      t.IDToken,
+		RefreshToken: t.RefreshToken,
+		TokenType:    t.TokenType,
+		Expiration:   t.Expiration,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It restores a Token saved with Persist. If the expiration is missing it is
+// taken from the exp claims of the tokens. Redacted output of MarshalJSON is rejected.
+func (t *Token) UnmarshalJSON(data []byte) error {
+	var tj tokenJSON
+	if err := json.Unmarshal(data, &tj); err != nil {
+		return err
+	}
+
+	if tj.AccessToken == "" && tj.IDToken == "" && tj.RefreshToken == "" {
+		return errors.New("token contains no access, ID or refresh token")
+	}
+
+	if tj.AccessToken == logging.Redacted || tj.IDToken == logging.Redacted || tj.RefreshToken == logging.Redacted {
+		return errors.New("token is redacted, save it with Persist")
+	}
+
+	*t = Token{
+		AccessToken:  tj.AccessToken,
+		IDToken:      tj.IDToken,
+		RefreshToken: tj.RefreshToken,
+		TokenType:    tj.TokenType,
+		Expiration:   tj.Expiration,
+	}
+
+	if t.Expiration.IsZero() {
+		t.Expiration, _ = t.claimsExpiration()
+	}
+
+	return nil
 }
 
-func (t *Token) updateToken(authenticationResult *cip.AuthenticationResultType) *Token {
-	t.AccessToken = aws.StringValue(authenticationResult.AccessToken)
-	t.IDToken = aws.StringValue(authenticationResult.IdToken)
-	t.RefreshToken = aws.StringValue(authenticationResult.RefreshToken)
-	t.TokenType = aws.StringValue(authenticationResult.TokenType)
-	t.Expiration = time.Now().Add(time.Duration(*authenticationResult.ExpiresIn) * time.Second)
+func (t *Token) updateToken(authenticationResult *idp.AuthenticationResult, now time.Time) *Token {
+	t.AccessToken = authenticationResult.AccessToken
+	t.IDToken = authenticationResult.IDToken
+	// Cognito does not return a new refresh token when refreshing, so the existing one is kept.
+	if authenticationResult.RefreshToken != "" {
+		t.RefreshToken = authenticationResult.RefreshToken
+	}
+	t.TokenType = authenticationResult.TokenType
+
+	if exp, ok := t.claimsExpiration(); ok {
+		t.Expiration = exp
+	} else {
+		t.Expiration = now.Add(time.Duration(authenticationResult.ExpiresIn) * time.Second)
+	}
 
 	return t
 }
@@ -51,31 +248,81 @@ func (t *Token) getRequestMetadata() map[string]string {
 	return metadata
 }
 
+// identityProvider is the part of the Cognito Identity Provider API used by TokenSource, in the plain types of
+// internal/idp. It is implemented by idp.Client, used with Config.Lightweight, and by sdkProvider, which adapts the
+// aws-sdk-go client.
+type identityProvider interface {
+	InitiateAuth(context.Context, *idp.InitiateAuthInput) (*idp.AuthOutput, error)
+	RespondToAuthChallenge(context.Context, *idp.RespondToAuthChallengeInput) (*idp.AuthOutput, error)
+	ChangePassword(context.Context, *idp.ChangePasswordInput) error
+	GetUser(context.Context, *idp.AccessTokenInput) (*idp.GetUserOutput, error)
+	UpdateUserAttributes(context.Context, *idp.UpdateUserAttributesInput) error
+	VerifyUserAttribute(context.Context, *idp.VerifyUserAttributeInput) error
+	DeleteUserAttributes(context.Context, *idp.DeleteUserAttributesInput) error
+	SetUserMFAPreference(context.Context, *idp.SetUserMFAPreferenceInput) error
+	GlobalSignOut(context.Context, *idp.AccessTokenInput) error
+	ListDevices(context.Context, *idp.ListDevicesInput) (*idp.ListDevicesOutput, error)
+	GetDevice(context.Context, *idp.DeviceInput) (*idp.GetDeviceOutput, error)
+	UpdateDeviceStatus(context.Context, *idp.UpdateDeviceStatusInput) error
+	ForgetDevice(context.Context, *idp.DeviceInput) error
+	RevokeToken(context.Context, *idp.RevokeTokenInput) error
+}
+
+var _ identityProvider = (*idp.Client)(nil)
+
 // TokenSource handles the retrieval and refreshing of tokens
 type TokenSource struct {
 	config           *Config
 	userpoolName     string
-	identityProvider cognitoidentityprovideriface.CognitoIdentityProviderAPI
-	tkn              Token
+	identityProvider identityProvider
+
+	// sem guards tkn and password. It is a channel rather than a sync.Mutex so waiting for it can be canceled.
+	sem      chan struct{}
+	tkn      Token
+	password string
+
+	// rand is the source of randomness for the SRP private key. crypto/rand.Reader is used if nil.
+	rand io.Reader
 }
 
 // NewTokenSource returns a new TokenSource with the provided configuration
 func NewTokenSource(conf *Config) (*TokenSource, error) {
-	sess, err := session.NewSession(conf.AWSConfig)
+	region, err := userpoolRegion(conf.UserpoolID)
 	if err != nil {
-		return nil, fmt.Errorf("error getting Cognito session: %v", err)
+		return nil, err
 	}
 
 	ts := &TokenSource{
-		config:           conf,
-		userpoolName:     strings.Split(conf.UserpoolID, "_")[1],
-		identityProvider: cip.New(sess),
+		config:       conf,
+		userpoolName: strings.SplitN(conf.UserpoolID, "_", 2)[1],
+		password:     conf.Password,
+		sem:          make(chan struct{}, 1),
+	}
+
+	if conf.IdentityProvider != nil {
+		ts.identityProvider = sdkProvider{api: conf.IdentityProvider}
+
+		return ts, nil
 	}
 
+	if conf.Lightweight {
+		ip := idp.New(region, conf.HTTPClient)
+		if conf.Endpoint != "" {
+			ip.Endpoint = conf.Endpoint
+		}
+		ts.identityProvider = ip
+
+		return ts, nil
+	}
+
+	ts.identityProvider = sdkProvider{api: cip.New(newSDKConfigProvider(conf.AWSConfig, region))}
+
 	return ts, nil
 }
 
 // GetRequestMetadata is used to implement PerRPCCredentials interface.
+//
+// Deprecated: Use PerRPCCredentials which requires transport security by default.
 func (ts *TokenSource) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
 	token, err := ts.GetToken()
 	if err != nil {
@@ -86,153 +333,240 @@ func (ts *TokenSource) GetRequestMetadata(ctx context.Context, uri ...string) (m
 }
 
 // RequireTransportSecurity is used to implement PerRPCCredentials interface.
-// TODO: Make this default to true.
+//
+// Deprecated: Use PerRPCCredentials which requires transport security by default.
 func (ts *TokenSource) RequireTransportSecurity() bool {
 	return ts.config.RequireTransportSecurity
 }
 
 // GetToken returns the existing Token if valid or refreshes and returns the new Token.
 func (ts *TokenSource) GetToken() (*Token, error) {
-	if ts.tkn.AccessToken != "" && time.Now().Before(ts.tkn.Expiration) {
-		return &ts.tkn, nil
+	return ts.Token(context.Background())
+}
+
+// Token implements TokenProvider. It returns the existing Token if valid or refreshes and returns the new Token. The
+// calls to Cognito are canceled when ctx is done.
+func (ts *TokenSource) Token(ctx context.Context) (*Token, error) {
+	if err := ts.lockContext(ctx); err != nil {
+		return nil, err
+	}
+	defer ts.unlock()
+
+	if ts.tkn.validAt(ts.now()) {
+		ts.observe(Event{Type: EventCacheHit})
+		ts.logger().Debug("using cached token", "expiration", ts.tkn.Expiration)
+		return ts.copyToken(), nil
 	}
 
 	if ts.tkn.RefreshToken != "" {
-		authResponse, err := ts.refreshAuthToken()
+		ts.logger().Debug("refreshing token")
+		start := ts.now()
+		authResponse, err := ts.refreshAuthToken(ctx)
+		ts.observe(Event{Type: EventRefresh, Duration: ts.now().Sub(start), Err: err})
 		if err != nil {
+			ts.logger().Error("error refreshing token", "error", err)
 			return nil, fmt.Errorf("error refreshing Token: %v", err)
 		}
 
-		return ts.tkn.updateToken(authResponse), nil
+		ts.tkn.updateToken(authResponse, ts.now())
+		return ts.copyToken(), nil
 	}
 
-	authResponse, err := ts.authenticate()
+	ts.observe(Event{Type: EventAuthenticateStart})
+	ts.logger().Debug("authenticating", "username", ts.config.Username, "userpool", ts.config.UserpoolID)
+	start := ts.now()
+	authResponse, err := ts.authenticate(ctx)
+	ts.observe(Event{Type: EventAuthenticateDone, Duration: ts.now().Sub(start), Err: err})
 	if err != nil {
+		ts.logger().Error("error authenticating", "username", ts.config.Username, "error", err)
 		return nil, fmt.Errorf("error retrieving Token: %v", err)
 	}
 
-	return ts.tkn.updateToken(authResponse), nil
+	ts.tkn.updateToken(authResponse, ts.now())
+	return ts.copyToken(), nil
+}
+
+// copyToken returns a copy of the Token using the Clock of the TokenSource. Callers get a copy so they cannot modify
+// the Token held by the TokenSource. ts.sem must be held.
+func (ts *TokenSource) copyToken() *Token {
+	tkn := ts.tkn
+	tkn.clock = ts.config.Clock
+	return &tkn
+}
+
+// SetToken replaces the Token held by the TokenSource, eg. with a Token restored from a cache. If it has expired, the
+// next call to Token refreshes it with its refresh token. Like Token.Valid, a Token without Expiration never expires.
+func (ts *TokenSource) SetToken(t *Token) {
+	ts.lock()
+	defer ts.unlock()
+
+	ts.tkn = *t
+}
+
+func (ts *TokenSource) lock() {
+	ts.sem <- struct{}{}
+}
+
+// lockContext is like lock, but gives up and returns the error of ctx if ctx is done first.
+func (ts *TokenSource) lockContext(ctx context.Context) error {
+	select {
+	case ts.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (ts *TokenSource) unlock() {
+	<-ts.sem
 }
 
-func (ts *TokenSource) authenticate() (*cip.AuthenticationResultType, error) {
-	s, err := newSrp(generatePrivateKey())
+func (ts *TokenSource) random() io.Reader {
+	if ts.rand != nil {
+		return ts.rand
+	}
+	return rand.Reader
+}
+
+func (ts *TokenSource) authenticate(ctx context.Context) (*idp.AuthenticationResult, error) {
+	privateKey, err := generatePrivateKey(ts.random())
+	if err != nil {
+		return nil, fmt.Errorf("error generating private key: %v", err)
+	}
+
+	s, err := newSrp(privateKey)
 	if err != nil {
 		return nil, fmt.Errorf("error initiating srp: %v", err)
 	}
 
-	iar, err := ts.signIn(s)
+	iar, err := ts.signIn(ctx, s)
 	if err != nil {
 		return nil, fmt.Errorf("error initiating auth: %v", err)
 	}
 
-	rtac, err := ts.respondPasswordVerifier(iar, s)
+	ts.logger().Debug("responding to auth challenge", "challenge", iar.ChallengeName)
+	start := ts.now()
+	rtac, err := ts.respondPasswordVerifier(ctx, iar, s)
+	ts.observe(Event{Type: EventChallenge, Challenge: iar.ChallengeName, Duration: ts.now().Sub(start), Err: err})
 	if err != nil {
 		return nil, fmt.Errorf("error responding to auth challenge: %v", err)
 	}
 
-	if rtac.ChallengeName != nil && *rtac.ChallengeName == "NEW_PASSWORD_REQUIRED" {
-		tmpPassword := ts.config.Password + ":" + uuid.New().String()
-		res, err := ts.respondNewPasswordRequired(rtac, tmpPassword)
-
+	if rtac.ChallengeName == cip.ChallengeNameTypeNewPasswordRequired {
+		ts.logger().Info("new password required, setting temporary password and changing back", "username", ts.config.Username)
+		tmpPassword := ts.password + ":" + uuid.New().String()
+		start := ts.now()
+		res, err := ts.respondNewPasswordRequired(ctx, rtac, tmpPassword)
+		ts.observe(Event{Type: EventChallenge, Challenge: rtac.ChallengeName, Duration: ts.now().Sub(start), Err: err})
 		if err != nil {
 			return nil, fmt.Errorf("error setting new password: %v", err)
 		}
 
-		ts.tkn.updateToken(res.AuthenticationResult)
-		if err := ts.changePassword(tmpPassword, ts.config.Password); err != nil {
+		ts.tkn.updateToken(res.AuthenticationResult, ts.now())
+		if err := ts.changePassword(ctx, ts.tkn.AccessToken, tmpPassword, ts.password); err != nil {
 			return nil, fmt.Errorf("error changing password: %v", err)
 		}
 
 		return res.AuthenticationResult, nil
 	}
 
+	if rtac.AuthenticationResult == nil {
+		err := fmt.Errorf("unsupported challenge: %s", rtac.ChallengeName)
+		ts.observe(Event{Type: EventChallenge, Challenge: rtac.ChallengeName, Err: err})
+		ts.logger().Warn("unsupported auth challenge", "challenge", rtac.ChallengeName)
+		return nil, err
+	}
+
 	return rtac.AuthenticationResult, nil
 }
 
-func (ts *TokenSource) signIn(s *srp) (*cip.InitiateAuthOutput, error) {
-	params := &cip.InitiateAuthInput{
-		AuthFlow: aws.String(cip.AuthFlowTypeUserSrpAuth),
-		AuthParameters: map[string]*string{
-			"USERNAME": &ts.config.Username,
-			"SRP_A":    aws.String(s.getA().Text(16)),
+func (ts *TokenSource) signIn(ctx context.Context, s *srp) (*idp.AuthOutput, error) {
+	params := &idp.InitiateAuthInput{
+		AuthFlow: cip.AuthFlowTypeUserSrpAuth,
+		AuthParameters: map[string]string{
+			"USERNAME": ts.config.Username,
+			"SRP_A":    s.getA().Text(16),
 		},
-		ClientId: &ts.config.ClientID,
+		ClientID: ts.config.ClientID,
 	}
 
-	return ts.identityProvider.InitiateAuth(params)
+	return ts.identityProvider.InitiateAuth(ctx, params)
 }
 
-func (ts *TokenSource) respondPasswordVerifier(initAuthResponse *cip.InitiateAuthOutput, s *srp) (*cip.RespondToAuthChallengeOutput, error) {
-	salt, ok := big.NewInt(0).SetString(*initAuthResponse.ChallengeParameters["SALT"], 16)
+func (ts *TokenSource) respondPasswordVerifier(ctx context.Context, initAuthResponse *idp.AuthOutput, s *srp) (*idp.AuthOutput, error) {
+	salt, ok := big.NewInt(0).SetString(initAuthResponse.ChallengeParameters["SALT"], 16)
 	if !ok {
-		return nil, fmt.Errorf("error parsing salt value: %s", *initAuthResponse.ChallengeParameters["SALT"])
+		return nil, fmt.Errorf("error parsing salt value: %s", initAuthResponse.ChallengeParameters["SALT"])
 	}
 
-	xB, ok := big.NewInt(0).SetString(*initAuthResponse.ChallengeParameters["SRP_B"], 16)
+	xB, ok := big.NewInt(0).SetString(initAuthResponse.ChallengeParameters["SRP_B"], 16)
 	if !ok {
-		return nil, fmt.Errorf("error parsing B value: %s", *initAuthResponse.ChallengeParameters["SALT"])
+		return nil, fmt.Errorf("error parsing B value: %s", initAuthResponse.ChallengeParameters["SALT"])
 	}
 
-	secretBlock, err := base64.StdEncoding.DecodeString(*initAuthResponse.ChallengeParameters["SECRET_BLOCK"])
+	secretBlock, err := base64.StdEncoding.DecodeString(initAuthResponse.ChallengeParameters["SECRET_BLOCK"])
 	if err != nil {
-		return nil, fmt.Errorf("error parsing secret block: %s", *initAuthResponse.ChallengeParameters["SECRET_BLOCK"])
+		return nil, fmt.Errorf("error parsing secret block: %s", initAuthResponse.ChallengeParameters["SECRET_BLOCK"])
 	}
 
-	dateStr := time.Now().UTC().Format(timestampFormat)
+	dateStr := ts.now().UTC().Format(timestampFormat)
 
-	signature, err := s.getSignature(ts.userpoolName, ts.config.Username, ts.config.Password, dateStr, salt, xB, secretBlock)
+	signature, err := s.getSignature(ts.userpoolName, ts.config.Username, ts.password, dateStr, salt, xB, secretBlock)
 	if err != nil {
 		return nil, fmt.Errorf("error getting signature value: %v", err)
 	}
 
-	params := &cognitoidentityprovider.RespondToAuthChallengeInput{
+	params := &idp.RespondToAuthChallengeInput{
 		ChallengeName: initAuthResponse.ChallengeName,
-		ChallengeResponses: map[string]*string{
+		ChallengeResponses: map[string]string{
 			"PASSWORD_CLAIM_SECRET_BLOCK": initAuthResponse.ChallengeParameters["SECRET_BLOCK"],
-			"PASSWORD_CLAIM_SIGNATURE":    &signature,
-			"TIMESTAMP":                   &dateStr,
-			"USERNAME":                    &ts.config.Username,
+			"PASSWORD_CLAIM_SIGNATURE":    signature,
+			"TIMESTAMP":                   dateStr,
+			"USERNAME":                    ts.config.Username,
 		},
-		ClientId: &ts.config.ClientID,
+		ClientID: ts.config.ClientID,
 	}
 
-	return ts.identityProvider.RespondToAuthChallenge(params)
+	return ts.identityProvider.RespondToAuthChallenge(ctx, params)
 }
 
-func (ts *TokenSource) respondNewPasswordRequired(challengeOutput *cip.RespondToAuthChallengeOutput, newPassword string) (*cip.RespondToAuthChallengeOutput, error) {
-	params := &cognitoidentityprovider.RespondToAuthChallengeInput{
+func (ts *TokenSource) respondNewPasswordRequired(ctx context.Context, challengeOutput *idp.AuthOutput, newPassword string) (*idp.AuthOutput, error) {
+	params := &idp.RespondToAuthChallengeInput{
 		ChallengeName: challengeOutput.ChallengeName,
-		ChallengeResponses: map[string]*string{
-			"USERNAME":     &ts.config.Username,
-			"NEW_PASSWORD": &newPassword,
+		ChallengeResponses: map[string]string{
+			"USERNAME":     ts.config.Username,
+			"NEW_PASSWORD": newPassword,
 		},
-		ClientId: &ts.config.ClientID,
+		ClientID: ts.config.ClientID,
 		Session:  challengeOutput.Session,
 	}
 
-	return ts.identityProvider.RespondToAuthChallenge(params)
+	return ts.identityProvider.RespondToAuthChallenge(ctx, params)
 }
 
-func (ts *TokenSource) changePassword(oldPassword, newPassword string) error {
-	params := &cip.ChangePasswordInput{
-		AccessToken:      &ts.tkn.AccessToken,
-		PreviousPassword: &oldPassword,
-		ProposedPassword: &newPassword,
+func (ts *TokenSource) changePassword(ctx context.Context, accessToken, oldPassword, newPassword string) error {
+	params := &idp.ChangePasswordInput{
+		AccessToken:      accessToken,
+		PreviousPassword: oldPassword,
+		ProposedPassword: newPassword,
 	}
 
-	_, err := ts.identityProvider.ChangePassword(params)
-	return err
+	return ts.identityProvider.ChangePassword(ctx, params)
 }
 
-func (ts *TokenSource) refreshAuthToken() (*cip.AuthenticationResultType, error) {
-	params := &cip.InitiateAuthInput{
-		AuthFlow: aws.String(cip.AuthFlowTypeRefreshTokenAuth),
-		AuthParameters: map[string]*string{
-			"REFRESH_TOKEN": &ts.tkn.RefreshToken,
+func (ts *TokenSource) refreshAuthToken(ctx context.Context) (*idp.AuthenticationResult, error) {
+	params := &idp.InitiateAuthInput{
+		AuthFlow: cip.AuthFlowTypeRefreshTokenAuth,
+		AuthParameters: map[string]string{
+			"REFRESH_TOKEN": ts.tkn.RefreshToken,
 		},
-		ClientId: &ts.config.ClientID,
+		ClientID: ts.config.ClientID,
+	}
+
+	res, err := ts.identityProvider.InitiateAuth(ctx, params)
+	if err != nil {
+		return nil, err
 	}
 
-	res, err := ts.identityProvider.InitiateAuth(params)
-	return res.AuthenticationResult, err
+	return res.AuthenticationResult, nil
 }
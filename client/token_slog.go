@@ -0,0 +1,17 @@
+//go:build go1.21
+// +build go1.21
+
+package client
+
+import "log/slog"
+
+// LogValue implements slog.LogValuer. The token values are redacted.
+func (t Token) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("token_type", t.TokenType),
+		slog.String("access_token", redact(t.AccessToken)),
+		slog.String("id_token", redact(t.IDToken)),
+		slog.String("refresh_token", redact(t.RefreshToken)),
+		slog.Time("expiration", t.Expiration),
+	)
+}
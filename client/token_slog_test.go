@@ -0,0 +1,33 @@
+//go:build go1.21
+// +build go1.21
+
+package client
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToken_LogValue(t *testing.T) {
+	tkn := &Token{
+		AccessToken:  "secretAccessToken",
+		IDToken:      "secretIDToken",
+		RefreshToken: "secretRefreshToken",
+		TokenType:    "Bearer",
+		Expiration:   time.Now().Add(time.Hour),
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("token", "token", tkn)
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("token", "token", tkn)
+
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("Log contains token: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "Bearer") {
+		t.Errorf("Log is missing token type: %s", buf.String())
+	}
+}
@@ -1,13 +1,22 @@
 package client
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
 	"github.com/aws/aws-sdk-go/service/cognitoidentityprovider/cognitoidentityprovideriface"
+	"github.com/larwef/cognito/internal/idp"
 )
 
 func TestTokenSource_getToken(t *testing.T) {
@@ -78,8 +87,8 @@ func TestTokenSource_getToken_NewPasswordRequired(t *testing.T) {
 		if !strings.Contains(*cpi.PreviousPassword, "password") {
 			t.Error("Unexpected value for PreviousPassword")
 		}
-		if *cpi.ProposedPassword != ts.config.Password {
-			t.Errorf("Unexpected value: %v for ProposedPassword. Expected: %v", *cpi.ProposedPassword, ts.config.Password)
+		if *cpi.ProposedPassword != ts.password {
+			t.Errorf("Unexpected value: %v for ProposedPassword. Expected: %v", *cpi.ProposedPassword, ts.password)
 		}
 		return &cip.ChangePasswordOutput{}, nil
 	}
@@ -171,15 +180,122 @@ func TestTokenSource_getToken_ExpiredToken_WithoutRefreshToken(t *testing.T) {
 	}
 }
 
+func TestTokenSource_Token_ConcurrentRefresh(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getTokenSource(cognitoMock)
+	ts.tkn.AccessToken = "oldAccessToken"
+	ts.tkn.RefreshToken = "oldRefreshToken"
+	ts.tkn.Expiration = time.Now().Add(-1 * time.Minute)
+
+	refreshes := 0
+	cognitoMock.initiateAuthhandler = func(iai *cip.InitiateAuthInput) (*cip.InitiateAuthOutput, error) {
+		refreshes++
+		return &cip.InitiateAuthOutput{
+			AuthenticationResult: &cip.AuthenticationResultType{
+				AccessToken: aws.String("refreshedAccessToken"),
+				IdToken:     aws.String("refreshedIdToken"),
+				ExpiresIn:   aws.Int64(3600),
+				TokenType:   aws.String("Bearer"),
+			},
+		}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			tkn, err := ts.Token(context.Background())
+			if err != nil {
+				t.Errorf("Token returned an error: %v", err)
+				return
+			}
+
+			if tkn.AccessToken != "refreshedAccessToken" {
+				t.Errorf("Unexpected value: %v for AccessToken. Expected: %v", tkn.AccessToken, "refreshedAccessToken")
+			}
+			// The returned Token belongs to the caller. Run with -race to detect sharing with the TokenSource.
+			tkn.AccessToken = ""
+		}()
+	}
+	wg.Wait()
+
+	if refreshes != 1 {
+		t.Errorf("Unexpected number of refreshes: %d. Expected: 1", refreshes)
+	}
+}
+
+func TestTokenSource_Token_CanceledWhileWaiting(t *testing.T) {
+	ts := getTokenSource(nil)
+	ts.tkn.AccessToken = "AccessToken"
+	ts.tkn.Expiration = time.Now().Add(1 * time.Hour)
+
+	ts.lock()
+	defer ts.unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := ts.Token(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Unexpected error: %v. Expected: %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestTokenSource_getToken_Lightweight(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target := r.Header.Get("X-Amz-Target"); target != "AWSCognitoIdentityProviderService.InitiateAuth" {
+			t.Errorf("Unexpected X-Amz-Target: %s", target)
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Write([]byte(`{"AuthenticationResult":{"AccessToken":"refreshedAccessToken","ExpiresIn":3600,"IdToken":"refreshedIdToken","TokenType":"Bearer"}}`))
+	}))
+	defer server.Close()
+
+	ts, err := NewTokenSource(&Config{
+		UserpoolID:  "eu-west-1_userpoolId",
+		ClientID:    "clientId",
+		Lightweight: true,
+		Endpoint:    server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewTokenSource returned an error: %v", err)
+	}
+	ts.tkn.RefreshToken = "RefreshToken"
+
+	tkn, err := ts.GetToken()
+	if err != nil {
+		t.Errorf("GetToken returned an error: %v", err)
+	}
+
+	if tkn.AccessToken != "refreshedAccessToken" {
+		t.Error("AccessToken has unexpected value")
+	}
+}
+
 // Mock and helper functions
 type mockCognito struct {
 	cognitoidentityprovideriface.CognitoIdentityProviderAPI
 	initiateAuthhandler           func(*cip.InitiateAuthInput) (*cip.InitiateAuthOutput, error)
 	respondToAuthChallengeHandler func(*cip.RespondToAuthChallengeInput) (*cip.RespondToAuthChallengeOutput, error)
 	changePasswordHandler         func(*cip.ChangePasswordInput) (*cip.ChangePasswordOutput, error)
+	getUserHandler                func(*cip.GetUserInput) (*cip.GetUserOutput, error)
+	updateUserAttributesHandler   func(*cip.UpdateUserAttributesInput) (*cip.UpdateUserAttributesOutput, error)
+	verifyUserAttributeHandler    func(*cip.VerifyUserAttributeInput) (*cip.VerifyUserAttributeOutput, error)
+	deleteUserAttributesHandler   func(*cip.DeleteUserAttributesInput) (*cip.DeleteUserAttributesOutput, error)
+	setUserMFAPreferenceHandler   func(*cip.SetUserMFAPreferenceInput) (*cip.SetUserMFAPreferenceOutput, error)
+	globalSignOutHandler          func(*cip.GlobalSignOutInput) (*cip.GlobalSignOutOutput, error)
+	revokeTokenHandler            func(clientID, token string) error
+	listDevicesHandler            func(*cip.ListDevicesInput) (*cip.ListDevicesOutput, error)
+	getDeviceHandler              func(*cip.GetDeviceInput) (*cip.GetDeviceOutput, error)
+	updateDeviceStatusHandler     func(*cip.UpdateDeviceStatusInput) (*cip.UpdateDeviceStatusOutput, error)
+	forgetDeviceHandler           func(*cip.ForgetDeviceInput) (*cip.ForgetDeviceOutput, error)
 }
 
 func (mc *mockCognito) InitiateAuth(iau *cip.InitiateAuthInput) (*cip.InitiateAuthOutput, error) {
+	if mc.initiateAuthhandler != nil {
+		return mc.initiateAuthhandler(iau)
+	}
 	if *iau.AuthFlow == cip.AuthFlowTypeRefreshTokenAuth {
 		return &cip.InitiateAuthOutput{
 			AuthenticationResult: &cip.AuthenticationResultType{
@@ -203,14 +319,68 @@ func (mc *mockCognito) InitiateAuth(iau *cip.InitiateAuthInput) (*cip.InitiateAu
 	}, nil
 }
 
-func (mc *mockCognito) RespondToAuthChallenge(rac *cip.RespondToAuthChallengeInput) (*cip.RespondToAuthChallengeOutput, error) {
+func (mc *mockCognito) InitiateAuthWithContext(ctx aws.Context, iau *cip.InitiateAuthInput, opts ...request.Option) (*cip.InitiateAuthOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return mc.InitiateAuth(iau)
+}
+
+func (mc *mockCognito) RespondToAuthChallengeWithContext(ctx aws.Context, rac *cip.RespondToAuthChallengeInput, opts ...request.Option) (*cip.RespondToAuthChallengeOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	return mc.respondToAuthChallengeHandler(rac)
 }
 
-func (mc *mockCognito) ChangePassword(cpi *cip.ChangePasswordInput) (*cip.ChangePasswordOutput, error) {
+func (mc *mockCognito) ChangePasswordWithContext(ctx aws.Context, cpi *cip.ChangePasswordInput, opts ...request.Option) (*cip.ChangePasswordOutput, error) {
 	return mc.changePasswordHandler(cpi)
 }
 
+func (mc *mockCognito) GetUserWithContext(ctx aws.Context, gui *cip.GetUserInput, opts ...request.Option) (*cip.GetUserOutput, error) {
+	return mc.getUserHandler(gui)
+}
+
+func (mc *mockCognito) UpdateUserAttributesWithContext(ctx aws.Context, uuai *cip.UpdateUserAttributesInput, opts ...request.Option) (*cip.UpdateUserAttributesOutput, error) {
+	return mc.updateUserAttributesHandler(uuai)
+}
+
+func (mc *mockCognito) VerifyUserAttributeWithContext(ctx aws.Context, vuai *cip.VerifyUserAttributeInput, opts ...request.Option) (*cip.VerifyUserAttributeOutput, error) {
+	return mc.verifyUserAttributeHandler(vuai)
+}
+
+func (mc *mockCognito) DeleteUserAttributesWithContext(ctx aws.Context, duai *cip.DeleteUserAttributesInput, opts ...request.Option) (*cip.DeleteUserAttributesOutput, error) {
+	return mc.deleteUserAttributesHandler(duai)
+}
+
+func (mc *mockCognito) SetUserMFAPreferenceWithContext(ctx aws.Context, sumpi *cip.SetUserMFAPreferenceInput, opts ...request.Option) (*cip.SetUserMFAPreferenceOutput, error) {
+	return mc.setUserMFAPreferenceHandler(sumpi)
+}
+
+func (mc *mockCognito) GlobalSignOutWithContext(ctx aws.Context, gsoi *cip.GlobalSignOutInput, opts ...request.Option) (*cip.GlobalSignOutOutput, error) {
+	return mc.globalSignOutHandler(gsoi)
+}
+
+func (mc *mockCognito) RevokeToken(ctx context.Context, clientID, token string) error {
+	return mc.revokeTokenHandler(clientID, token)
+}
+
+func (mc *mockCognito) ListDevicesWithContext(ctx aws.Context, ldi *cip.ListDevicesInput, opts ...request.Option) (*cip.ListDevicesOutput, error) {
+	return mc.listDevicesHandler(ldi)
+}
+
+func (mc *mockCognito) GetDeviceWithContext(ctx aws.Context, gdi *cip.GetDeviceInput, opts ...request.Option) (*cip.GetDeviceOutput, error) {
+	return mc.getDeviceHandler(gdi)
+}
+
+func (mc *mockCognito) UpdateDeviceStatusWithContext(ctx aws.Context, udsi *cip.UpdateDeviceStatusInput, opts ...request.Option) (*cip.UpdateDeviceStatusOutput, error) {
+	return mc.updateDeviceStatusHandler(udsi)
+}
+
+func (mc *mockCognito) ForgetDeviceWithContext(ctx aws.Context, fdi *cip.ForgetDeviceInput, opts ...request.Option) (*cip.ForgetDeviceOutput, error) {
+	return mc.forgetDeviceHandler(fdi)
+}
+
 func getTokenSource(mock cognitoidentityprovideriface.CognitoIdentityProviderAPI) *TokenSource {
 	conf := &Config{
 		UserpoolID: "eu-west-1_userpoolId",
@@ -222,6 +392,255 @@ func getTokenSource(mock cognitoidentityprovideriface.CognitoIdentityProviderAPI
 	return &TokenSource{
 		config:           conf,
 		userpoolName:     "userpoolId",
-		identityProvider: mock,
+		identityProvider: sdkProvider{api: mock},
+		password:         conf.Password,
+		sem:              make(chan struct{}, 1),
+	}
+}
+
+func TestToken_String(t *testing.T) {
+	tkn := Token{
+		AccessToken:  "secretAccessToken",
+		IDToken:      "secretIDToken",
+		RefreshToken: "secretRefreshToken",
+		TokenType:    "Bearer",
+		Expiration:   time.Now().Add(time.Hour),
+	}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		for _, v := range []interface{}{tkn, &tkn} {
+			out := fmt.Sprintf(format, v)
+			if strings.Contains(out, "secret") {
+				t.Errorf("%s of %T contains token: %s", format, v, out)
+			}
+			if !strings.Contains(out, "Bearer") {
+				t.Errorf("%s of %T is missing token type: %s", format, v, out)
+			}
+		}
+	}
+}
+
+func TestToken_JSON(t *testing.T) {
+	tkn := Token{
+		AccessToken:  "secretAccessToken",
+		IDToken:      "secretIDToken",
+		RefreshToken: "secretRefreshToken",
+		TokenType:    "Bearer",
+		Expiration:   time.Now().Add(time.Hour).Round(time.Second),
+	}
+
+	for _, v := range []interface{}{tkn, &tkn, map[string]interface{}{"token": tkn}} {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal returned an error: %v", err)
+		}
+
+		if strings.Contains(string(b), "secret") {
+			t.Errorf("JSON of %T contains token: %s", v, b)
+		}
+		if !strings.Contains(string(b), "Bearer") {
+			t.Errorf("JSON of %T is missing token type: %s", v, b)
+		}
+	}
+
+	b, err := json.Marshal(tkn)
+	if err != nil {
+		t.Fatalf("Marshal returned an error: %v", err)
+	}
+
+	var restored Token
+	if err := json.Unmarshal(b, &restored); err == nil {
+		t.Error("Expected Unmarshal to return an error for a redacted token")
+	}
+}
+
+func TestToken_Persist(t *testing.T) {
+	tkn := Token{
+		AccessToken:  "AccessToken",
+		IDToken:      "IDToken",
+		RefreshToken: "RefreshToken",
+		TokenType:    "Bearer",
+		Expiration:   time.Now().Add(time.Hour).Round(time.Second),
+	}
+
+	b, err := tkn.Persist()
+	if err != nil {
+		t.Fatalf("Persist returned an error: %v", err)
+	}
+
+	var restored Token
+	if err := json.Unmarshal(b, &restored); err != nil {
+		t.Fatalf("Unmarshal returned an error: %v", err)
+	}
+
+	if restored.AccessToken != tkn.AccessToken || restored.IDToken != tkn.IDToken || restored.RefreshToken != tkn.RefreshToken ||
+		restored.TokenType != tkn.TokenType || !restored.Expiration.Equal(tkn.Expiration) {
+		t.Errorf("Unexpected value: %#v. Expected: %#v", restored, tkn)
+	}
+
+	if !restored.Valid() {
+		t.Error("Restored token is not valid")
+	}
+
+	if err := json.Unmarshal([]byte(`{"token_type":"Bearer"}`), &restored); err == nil {
+		t.Error("Expected Unmarshal to return an error for a token without tokens")
+	}
+}
+
+func TestToken_ValidExpiresIn(t *testing.T) {
+	tkn := &Token{AccessToken: "AccessToken", Expiration: time.Now().Add(time.Hour)}
+	if !tkn.Valid() {
+		t.Error("Expected token to be valid")
+	}
+	if d := tkn.ExpiresIn(); d <= 59*time.Minute || d > time.Hour {
+		t.Errorf("Unexpected value: %v for ExpiresIn", d)
+	}
+
+	tkn.Expiration = time.Now().Add(-time.Minute)
+	if tkn.Valid() {
+		t.Error("Expected expired token to be invalid")
+	}
+	if tkn.ExpiresIn() >= 0 {
+		t.Errorf("Unexpected value: %v for ExpiresIn. Expected negative duration", tkn.ExpiresIn())
+	}
+
+	var nilToken *Token
+	if nilToken.Valid() {
+		t.Error("Expected nil token to be invalid")
+	}
+}
+
+func TestToken_Claims(t *testing.T) {
+	tkn := &Token{
+		IDToken:     testJWT(`{"token_use":"id","email":"user@example.com"}`),
+		AccessToken: testJWT(`{"token_use":"access","username":"user"}`),
+	}
+
+	idClaims, err := tkn.IDTokenClaims()
+	if err != nil {
+		t.Fatalf("IDTokenClaims returned an error: %v", err)
+	}
+	if idClaims["email"] != "user@example.com" {
+		t.Errorf("Unexpected value: %v for email", idClaims["email"])
+	}
+
+	accessClaims, err := tkn.AccessTokenClaims()
+	if err != nil {
+		t.Fatalf("AccessTokenClaims returned an error: %v", err)
+	}
+	if accessClaims["username"] != "user" {
+		t.Errorf("Unexpected value: %v for username", accessClaims["username"])
+	}
+
+	if _, err := (&Token{}).IDTokenClaims(); err == nil {
+		t.Error("Expected an error for an empty token")
+	}
+}
+
+func TestToken_updateToken_ExpirationFromClaims(t *testing.T) {
+	accessExp := time.Now().Add(time.Hour).Truncate(time.Second)
+	idExp := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+
+	tkn := (&Token{}).updateToken(&idp.AuthenticationResult{
+		AccessToken: testJWT(fmt.Sprintf(`{"token_use":"access","exp":%d}`, accessExp.Unix())),
+		IDToken:     testJWT(fmt.Sprintf(`{"token_use":"id","exp":%d}`, idExp.Unix())),
+		ExpiresIn:   7200,
+		TokenType:   "Bearer",
+	}, time.Now())
+
+	if !tkn.Expiration.Equal(idExp) {
+		t.Errorf("Unexpected value: %v for Expiration. Expected: %v", tkn.Expiration, idExp)
+	}
+
+	exp, err := tkn.AccessTokenExpiration()
+	if err != nil || !exp.Equal(accessExp) {
+		t.Errorf("Unexpected value: %v, %v for AccessTokenExpiration. Expected: %v", exp, err, accessExp)
+	}
+
+	exp, err = tkn.IDTokenExpiration()
+	if err != nil || !exp.Equal(idExp) {
+		t.Errorf("Unexpected value: %v, %v for IDTokenExpiration. Expected: %v", exp, err, idExp)
+	}
+
+	var restored Token
+	if err := json.Unmarshal([]byte(fmt.Sprintf(`{"access_token":%q,"id_token":%q}`, tkn.AccessToken, tkn.IDToken)), &restored); err != nil {
+		t.Fatalf("Unmarshal returned an error: %v", err)
+	}
+
+	if !restored.Expiration.Equal(idExp) {
+		t.Errorf("Unexpected value: %v for restored Expiration. Expected: %v", restored.Expiration, idExp)
+	}
+}
+
+func TestToken_updateToken_ExpiresInFallback(t *testing.T) {
+	tkn := (&Token{}).updateToken(&idp.AuthenticationResult{
+		AccessToken: "AccessToken",
+		IDToken:     "IDToken",
+		ExpiresIn:   3600,
+	}, time.Now())
+
+	if d := tkn.ExpiresIn(); d <= 59*time.Minute || d > time.Hour {
+		t.Errorf("Unexpected value: %v for ExpiresIn", d)
+	}
+
+	if _, err := tkn.AccessTokenExpiration(); err == nil {
+		t.Error("Expected AccessTokenExpiration to return an error for a token which is not a JWT")
+	}
+}
+
+func TestToken_updateToken_KeepsRefreshToken(t *testing.T) {
+	tkn := &Token{RefreshToken: "RefreshToken"}
+
+	tkn.updateToken(&idp.AuthenticationResult{
+		AccessToken: "refreshedAccessToken",
+		IDToken:     "refreshedIDToken",
+		ExpiresIn:   3600,
+	}, time.Now())
+
+	if tkn.RefreshToken != "RefreshToken" {
+		t.Errorf("Unexpected value: %q for RefreshToken. Expected: %q", tkn.RefreshToken, "RefreshToken")
+	}
+
+	tkn.updateToken(&idp.AuthenticationResult{
+		AccessToken:  "AccessToken",
+		IDToken:      "IDToken",
+		RefreshToken: "newRefreshToken",
+		ExpiresIn:    3600,
+	}, time.Now())
+
+	if tkn.RefreshToken != "newRefreshToken" {
+		t.Errorf("Unexpected value: %q for RefreshToken. Expected: %q", tkn.RefreshToken, "newRefreshToken")
+	}
+}
+
+// testJWT returns an unsigned JWT with the claims.
+func testJWT(claims string) string {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	return encode(`{"kid":"kid","alg":"RS256"}`) + "." + encode(claims) + ".c2ln"
+}
+
+func TestTokenSource_SetToken(t *testing.T) {
+	ts := getTokenSource(&mockCognito{})
+
+	ts.SetToken(&Token{
+		AccessToken:  "AccessToken",
+		RefreshToken: "RefreshToken",
+		Expiration:   time.Now().Add(-1 * time.Minute),
+	})
+
+	tkn, err := ts.GetToken()
+	if err != nil {
+		t.Fatalf("GetToken returned an error: %v", err)
+	}
+
+	if tkn.AccessToken != "refreshedAccessToken" {
+		t.Errorf("Unexpected value: %v for AccessToken. Expected: %v", tkn.AccessToken, "refreshedAccessToken")
+	}
+
+	if tkn.RefreshToken != "RefreshToken" {
+		t.Errorf("Unexpected value: %v for RefreshToken. Expected: %v", tkn.RefreshToken, "RefreshToken")
 	}
 }
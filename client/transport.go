@@ -37,33 +37,48 @@ package client
 
 import (
 	"errors"
-	"io"
 	"net/http"
-	"sync"
+	"net/url"
+	"path"
+	"strings"
 )
 
 // Transport is an http.RoundTripper that makes OAuth 2.0 HTTP requests,
 // wrapping a base RoundTripper and adding an Authorization header
 // with a token from the supplied Sources.
 //
+// Requests are canceled through their context, which applies both to
+// obtaining the token and to the round trip made by Base.
+//
 // Transport is a low-level mechanism. Most code will use the
 // higher-level Config.Client method instead.
 type Transport struct {
 	// Source supplies the token to add to outgoing requests'
 	// Authorization headers.
-	Source *TokenSource
+	Source TokenProvider
 
 	// Base is the base RoundTripper used to make HTTP requests.
 	// If nil, http.DefaultTransport is used.
 	Base http.RoundTripper
 
-	mu     sync.Mutex                      // guards modReq
-	modReq map[*http.Request]*http.Request // original -> modified
+	// AllowedHosts restricts which requests get the Authorization header. An entry is either a host, optionally with
+	// port, like "api.example.com", a wildcard like "*.example.com" matching its subdomains, or a URL prefix like
+	// "https://api.example.com/v1/". A URL prefix matches whole path segments of the cleaned request path, so
+	// "https://api.example.com/v1" does not match /v10 or /v1/../admin. Requests to other hosts are passed on to Base
+	// untouched. If empty, the token is added to requests to any host.
+	AllowedHosts []string
 }
 
 // RoundTrip authorizes and authenticates the request with an
 // access token from Transport's Source.
+//
+// The token is only added to requests to hosts in AllowedHosts, and never to a
+// request redirected to another origin than the one of the original request.
 func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.authorize(req) {
+		return t.base().RoundTrip(req)
+	}
+
 	reqBodyClosed := false
 	if req.Body != nil {
 		defer func() {
@@ -74,72 +89,122 @@ func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 	}
 
 	if t.Source == nil {
-		return nil, errors.New("cognito: Transport's TokenSource is nil")
+		return nil, errors.New("cognito: Transport's TokenProvider is nil")
 	}
-	token, err := t.Source.GetToken()
+	token, err := t.Source.Token(req.Context())
 	if err != nil {
 		return nil, err
 	}
 
 	req2 := cloneRequest(req) // per RoundTripper contract
 	token.setAuthHeader(req2)
-	t.setModReq(req, req2)
-	res, err := t.base().RoundTrip(req2)
 
-	// req.Body is assumed to have been closed by the base RoundTripper.
+	// req.Body is assumed to be closed by the base RoundTripper.
 	reqBodyClosed = true
+	return t.base().RoundTrip(req2)
+}
 
-	if err != nil {
-		t.setModReq(req, nil)
-		return nil, err
+// authorize reports whether the token should be added to req.
+func (t *Transport) authorize(req *http.Request) bool {
+	if !sameOrigin(req.URL, originalRequest(req).URL) {
+		return false
+	}
+
+	if len(t.AllowedHosts) == 0 {
+		return true
 	}
-	res.Body = &onEOFReader{
-		rc: res.Body,
-		fn: func() { t.setModReq(req, nil) },
+
+	for _, allowed := range t.AllowedHosts {
+		if hostAllowed(allowed, req.URL) {
+			return true
+		}
 	}
-	return res, nil
+
+	return false
 }
 
-// CancelRequest cancels an in-flight request by closing its connection.
-func (t *Transport) CancelRequest(req *http.Request) {
-	type canceler interface {
-		CancelRequest(*http.Request)
+// originalRequest follows the chain of redirects made by http.Client back to the first request.
+func originalRequest(req *http.Request) *http.Request {
+	for req.Response != nil && req.Response.Request != nil {
+		req = req.Response.Request
 	}
-	if cr, ok := t.base().(canceler); ok {
-		t.mu.Lock()
-		modReq := t.modReq[req]
-		delete(t.modReq, req)
-		t.mu.Unlock()
-		cr.CancelRequest(modReq)
+
+	return req
+}
+
+// sameOrigin reports whether a and b have the same scheme, host and port. A missing port is the default port of the
+// scheme, so https://example.com and https://example.com:443 are the same origin.
+func sameOrigin(a, b *url.URL) bool {
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Hostname(), b.Hostname()) && port(a) == port(b)
+}
+
+func port(u *url.URL) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		return "443"
+	case "http":
+		return "80"
 	}
+
+	return ""
 }
 
-func (t *Transport) base() http.RoundTripper {
-	if t.Base != nil {
-		return t.Base
+func hostAllowed(allowed string, u *url.URL) bool {
+	if strings.Contains(allowed, "://") {
+		prefix, err := url.Parse(allowed)
+		if err != nil {
+			return false
+		}
+
+		return sameOrigin(prefix, u) && pathHasPrefix(u.Path, prefix.Path)
 	}
-	return http.DefaultTransport
+
+	host := u.Host
+	if !strings.Contains(allowed, ":") {
+		host = u.Hostname()
+	}
+
+	if strings.HasPrefix(allowed, "*.") {
+		return len(host) > len(allowed)-1 && strings.HasSuffix(strings.ToLower(host), strings.ToLower(allowed[1:]))
+	}
+
+	return strings.EqualFold(allowed, host)
 }
 
-func (t *Transport) setModReq(orig, mod *http.Request) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	if t.modReq == nil {
-		t.modReq = make(map[*http.Request]*http.Request)
+// pathHasPrefix reports whether p is prefix or a path below it. Only whole segments match, so /v1 matches /v1 and
+// /v1/resource but not /v10. p is cleaned first, so dot segments cannot lead out of prefix.
+func pathHasPrefix(p, prefix string) bool {
+	p = cleanPath(p)
+	if !strings.HasPrefix(p, prefix) {
+		return false
 	}
-	if mod == nil {
-		delete(t.modReq, orig)
-	} else {
-		t.modReq[orig] = mod
+
+	return prefix == "" || strings.HasSuffix(prefix, "/") || len(p) == len(prefix) || p[len(prefix)] == '/'
+}
+
+// cleanPath returns p with dot segments and duplicate slashes removed, keeping a trailing slash.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
 	}
+
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+
+	return cleaned
 }
 
-// cloneRequest returns a clone of the provided *http.Request.
-// The clone is a shallow copy of the struct and its Header map.
+// cloneRequest returns a clone of the provided *http.Request, keeping its context. The clone is a shallow copy of the
+// struct and its Header map. http.Request.Clone does the same, but requires Go 1.13.
 func cloneRequest(r *http.Request) *http.Request {
-	// shallow copy of the struct
-	r2 := new(http.Request)
-	*r2 = *r
+	// WithContext makes a shallow copy of the struct
+	r2 := r.WithContext(r.Context())
 	// deep copy of the Header
 	r2.Header = make(http.Header, len(r.Header))
 	for k, s := range r.Header {
@@ -148,28 +213,9 @@ func cloneRequest(r *http.Request) *http.Request {
 	return r2
 }
 
-type onEOFReader struct {
-	rc io.ReadCloser
-	fn func()
-}
-
-func (r *onEOFReader) Read(p []byte) (n int, err error) {
-	n, err = r.rc.Read(p)
-	if err == io.EOF {
-		r.runFunc()
-	}
-	return
-}
-
-func (r *onEOFReader) Close() error {
-	err := r.rc.Close()
-	r.runFunc()
-	return err
-}
-
-func (r *onEOFReader) runFunc() {
-	if fn := r.fn; fn != nil {
-		fn()
-		r.fn = nil
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
 	}
+	return http.DefaultTransport
 }
@@ -0,0 +1,251 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTransport_AllowedHosts(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		allowed  []string
+		expected string
+	}{
+		{nil, "IDToken"},
+		{[]string{serverURL.Host}, "IDToken"},
+		{[]string{serverURL.Hostname()}, "IDToken"},
+		{[]string{server.URL + "/api/"}, "IDToken"},
+		{[]string{server.URL + "/other/"}, ""},
+		{[]string{"api.example.com"}, ""},
+		{[]string{"*.example.com"}, ""},
+	}
+
+	for _, test := range tests {
+		httpClient := &http.Client{
+			Transport: &Transport{
+				Source:       StaticTokenSource(&Token{IDToken: "IDToken"}),
+				AllowedHosts: test.allowed,
+			},
+		}
+
+		authorization = ""
+		res, err := httpClient.Get(server.URL + "/api/resource")
+		if err != nil {
+			t.Fatalf("Get returned an error: %v", err)
+		}
+		res.Body.Close()
+
+		if authorization != test.expected {
+			t.Errorf("Unexpected value: %q for Authorization with AllowedHosts %v. Expected: %q", authorization, test.allowed, test.expected)
+		}
+	}
+}
+
+func TestTransport_Redirect(t *testing.T) {
+	var authorization string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	defer other.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "IDToken" {
+			t.Error("Expected token on request to the original host")
+		}
+		http.Redirect(w, r, other.URL, http.StatusFound)
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{
+		Transport: &Transport{Source: StaticTokenSource(&Token{IDToken: "IDToken"})},
+	}
+
+	authorization = "unset"
+	res, err := httpClient.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	res.Body.Close()
+
+	if authorization != "" {
+		t.Errorf("Token was forwarded to another origin: %q", authorization)
+	}
+}
+
+func TestHostAllowed(t *testing.T) {
+	tests := []struct {
+		allowed  string
+		url      string
+		expected bool
+	}{
+		{"api.example.com", "https://api.example.com/path", true},
+		{"api.example.com", "https://API.example.com:8443/path", true},
+		{"api.example.com:8443", "https://api.example.com/path", false},
+		{"api.example.com", "https://api.example.com.evil.com/path", false},
+		{"*.example.com", "https://api.example.com/path", true},
+		{"*.example.com", "https://example.com/path", false},
+		{"*.example.com", "https://evilexample.com/path", false},
+		{"https://api.example.com/v1/", "https://api.example.com/v1/resource", true},
+		{"https://api.example.com/v1/", "https://api.example.com/v2/resource", false},
+		{"https://api.example.com/v1/", "http://api.example.com/v1/resource", false},
+		{"https://api.example.com", "https://api.example.com.evil.com/", false},
+		{"https://api.example.com", "https://api.example.com/path", true},
+		{"https://api.example.com/v1", "https://api.example.com/v1", true},
+		{"https://api.example.com/v1", "https://api.example.com/v1/resource", true},
+		{"https://api.example.com/v1", "https://api.example.com/v10/resource", false},
+		{"https://api.example.com/v1", "https://api.example.com/v1-admin", false},
+		{"https://api.example.com/v1/", "https://api.example.com/v1", false},
+		{"https://api.example.com/v1/", "https://api.example.com/v1/../admin", false},
+		{"https://api.example.com/v1/", "https://api.example.com/v1/%2e%2e/admin", false},
+		{"https://api.example.com/v1/", "https://api.example.com/v1//resource", true},
+		{"https://api.example.com/v1/", "https://api.example.com:443/v1/resource", true},
+		{"http://api.example.com:80/v1/", "http://api.example.com/v1/resource", true},
+		{"https://api.example.com/v1/", "https://api.example.com:8443/v1/resource", false},
+	}
+
+	for _, test := range tests {
+		u, err := url.Parse(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if allowed := hostAllowed(test.allowed, u); allowed != test.expected {
+			t.Errorf("Unexpected value: %v for %s and %s. Expected: %v", allowed, test.allowed, test.url, test.expected)
+		}
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"https://api.example.com/a", "https://API.example.com/b", true},
+		{"https://api.example.com", "https://api.example.com:443", true},
+		{"http://api.example.com", "http://api.example.com:80", true},
+		{"http://api.example.com", "https://api.example.com", false},
+		{"https://api.example.com", "https://api.example.com:80", false},
+		{"https://api.example.com:8443", "https://api.example.com", false},
+		{"https://api.example.com", "https://example.com", false},
+	}
+
+	for _, test := range tests {
+		a, err := url.Parse(test.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := url.Parse(test.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if same := sameOrigin(a, b); same != test.expected {
+			t.Errorf("Unexpected value: %v for %s and %s. Expected: %v", same, test.a, test.b, test.expected)
+		}
+	}
+}
+
+func TestTransport_CancelTokenAcquisition(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Request should not reach the server")
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: &Transport{Source: blockingTokenProvider{}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = httpClient.Do(req.WithContext(ctx))
+	if urlErr, ok := err.(*url.Error); !ok || urlErr.Err != context.Canceled {
+		t.Errorf("Expected error: %v. Got: %v", context.Canceled, err)
+	}
+}
+
+func TestTransport_CancelRoundTrip(t *testing.T) {
+	started := make(chan struct{})
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	httpClient := &http.Client{
+		Transport: &Transport{Source: StaticTokenSource(&Token{IDToken: "IDToken"})},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := httpClient.Do(req.WithContext(ctx))
+		errc <- err
+	}()
+
+	select {
+	case err := <-errc:
+		// The error for a request canceled in flight varies between Go versions.
+		if err == nil {
+			t.Error("Expected an error for the canceled request")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Request was not canceled")
+	}
+}
+
+func TestTransport_DoesNotModifyRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	httpClient := &http.Client{
+		Transport: &Transport{Source: StaticTokenSource(&Token{IDToken: "IDToken"})},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do returned an error: %v", err)
+	}
+	res.Body.Close()
+
+	if req.Header.Get("Authorization") != "" {
+		t.Error("Transport modified the original request")
+	}
+}
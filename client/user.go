@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/larwef/cognito/internal/idp"
+)
+
+// User holds the profile of the user authenticated by a TokenSource.
+type User struct {
+	Username            string
+	Attributes          map[string]string
+	PreferredMFASetting string
+	MFASettings         []string
+}
+
+// MFAPreference holds the users preference for a single MFA method.
+type MFAPreference struct {
+	Enabled   bool
+	Preferred bool
+}
+
+// GetUser returns the profile of the authenticated user. Like the other methods on the user and its devices, the calls
+// to Cognito, including getting a token, are canceled when ctx is done.
+func (ts *TokenSource) GetUser(ctx context.Context) (*User, error) {
+	accessToken, err := ts.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := ts.identityProvider.GetUser(ctx, &idp.AccessTokenInput{
+		AccessToken: accessToken,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting user: %v", err)
+	}
+
+	user := &User{
+		Username:            res.Username,
+		Attributes:          make(map[string]string),
+		PreferredMFASetting: res.PreferredMfaSetting,
+		MFASettings:         res.UserMFASettingList,
+	}
+	for _, attribute := range res.UserAttributes {
+		user.Attributes[attribute.Name] = attribute.Value
+	}
+
+	return user, nil
+}
+
+// UpdateUserAttributes creates or updates the given attributes on the authenticated user. Attributes requiring
+// verification, like email and phone_number, will trigger a verification code being sent to the user.
+func (ts *TokenSource) UpdateUserAttributes(ctx context.Context, attributes map[string]string) error {
+	accessToken, err := ts.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var userAttributes []idp.Attribute
+	for name, value := range attributes {
+		userAttributes = append(userAttributes, idp.Attribute{
+			Name:  name,
+			Value: value,
+		})
+	}
+
+	err = ts.identityProvider.UpdateUserAttributes(ctx, &idp.UpdateUserAttributesInput{
+		AccessToken:    accessToken,
+		UserAttributes: userAttributes,
+	})
+	if err != nil {
+		return fmt.Errorf("error updating user attributes: %v", err)
+	}
+
+	return nil
+}
+
+// VerifyUserAttribute verifies an attribute on the authenticated user using the code sent to the user.
+func (ts *TokenSource) VerifyUserAttribute(ctx context.Context, name, code string) error {
+	accessToken, err := ts.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = ts.identityProvider.VerifyUserAttribute(ctx, &idp.VerifyUserAttributeInput{
+		AccessToken:   accessToken,
+		AttributeName: name,
+		Code:          code,
+	})
+	if err != nil {
+		return fmt.Errorf("error verifying user attribute %s: %v", name, err)
+	}
+
+	return nil
+}
+
+// DeleteUserAttributes deletes the named attributes from the authenticated user.
+func (ts *TokenSource) DeleteUserAttributes(ctx context.Context, names ...string) error {
+	accessToken, err := ts.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = ts.identityProvider.DeleteUserAttributes(ctx, &idp.DeleteUserAttributesInput{
+		AccessToken:        accessToken,
+		UserAttributeNames: names,
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting user attributes: %v", err)
+	}
+
+	return nil
+}
+
+// ChangePassword changes the password of the authenticated user. The TokenSource uses the new password for later
+// authentications. The Config used to create it is not modified.
+func (ts *TokenSource) ChangePassword(ctx context.Context, oldPassword, newPassword string) error {
+	accessToken, err := ts.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := ts.changePassword(ctx, accessToken, oldPassword, newPassword); err != nil {
+		return fmt.Errorf("error changing password: %v", err)
+	}
+
+	ts.lock()
+	ts.password = newPassword
+	ts.unlock()
+
+	return nil
+}
+
+// SetUserMFAPreference sets the MFA preferences of the authenticated user. A nil preference leaves the setting for that
+// MFA method unchanged.
+func (ts *TokenSource) SetUserMFAPreference(ctx context.Context, sms, softwareToken *MFAPreference) error {
+	accessToken, err := ts.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	params := &idp.SetUserMFAPreferenceInput{
+		AccessToken: accessToken,
+	}
+	if sms != nil {
+		params.SMSMfaSettings = &idp.MFASettings{
+			Enabled:      sms.Enabled,
+			PreferredMfa: sms.Preferred,
+		}
+	}
+	if softwareToken != nil {
+		params.SoftwareTokenMfaSettings = &idp.MFASettings{
+			Enabled:      softwareToken.Enabled,
+			PreferredMfa: softwareToken.Preferred,
+		}
+	}
+
+	if err := ts.identityProvider.SetUserMFAPreference(ctx, params); err != nil {
+		return fmt.Errorf("error setting user MFA preference: %v", err)
+	}
+
+	return nil
+}
+
+// accessToken returns the current access token, refreshing or authenticating first if necessary.
+func (ts *TokenSource) accessToken(ctx context.Context) (string, error) {
+	token, err := ts.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return token.AccessToken, nil
+}
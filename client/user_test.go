@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+)
+
+func TestTokenSource_GetUser(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	cognitoMock.getUserHandler = func(gui *cip.GetUserInput) (*cip.GetUserOutput, error) {
+		if *gui.AccessToken != "AccessToken" {
+			t.Errorf("Unexpected value: %v for AccessToken. Expected: %v", *gui.AccessToken, "AccessToken")
+		}
+		return &cip.GetUserOutput{
+			Username: aws.String("user"),
+			UserAttributes: []*cip.AttributeType{
+				{Name: aws.String("email"), Value: aws.String("user@example.com")},
+			},
+			PreferredMfaSetting: aws.String("SOFTWARE_TOKEN_MFA"),
+			UserMFASettingList:  aws.StringSlice([]string{"SOFTWARE_TOKEN_MFA"}),
+		}, nil
+	}
+
+	user, err := ts.GetUser(context.Background())
+	if err != nil {
+		t.Errorf("GetUser returned an error: %v", err)
+	}
+
+	if user.Username != "user" {
+		t.Errorf("Unexpected value: %v for Username. Expected: %v", user.Username, "user")
+	}
+
+	if user.Attributes["email"] != "user@example.com" {
+		t.Errorf("Unexpected value: %v for email. Expected: %v", user.Attributes["email"], "user@example.com")
+	}
+
+	if user.PreferredMFASetting != "SOFTWARE_TOKEN_MFA" {
+		t.Error("PreferredMFASetting has unexpected value")
+	}
+
+	if len(user.MFASettings) != 1 {
+		t.Errorf("Unexpected number of MFA settings %d", len(user.MFASettings))
+	}
+}
+
+func TestTokenSource_UpdateUserAttributes(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	cognitoMock.updateUserAttributesHandler = func(uuai *cip.UpdateUserAttributesInput) (*cip.UpdateUserAttributesOutput, error) {
+		if len(uuai.UserAttributes) != 1 {
+			t.Fatalf("Unexpected number of attributes %d", len(uuai.UserAttributes))
+		}
+		if *uuai.UserAttributes[0].Name != "name" || *uuai.UserAttributes[0].Value != "Test User" {
+			t.Error("Attribute has unexpected value")
+		}
+		return &cip.UpdateUserAttributesOutput{}, nil
+	}
+
+	if err := ts.UpdateUserAttributes(context.Background(), map[string]string{"name": "Test User"}); err != nil {
+		t.Errorf("UpdateUserAttributes returned an error: %v", err)
+	}
+}
+
+func TestTokenSource_VerifyUserAttribute(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	cognitoMock.verifyUserAttributeHandler = func(vuai *cip.VerifyUserAttributeInput) (*cip.VerifyUserAttributeOutput, error) {
+		if *vuai.AttributeName != "email" || *vuai.Code != "123456" {
+			t.Error("VerifyUserAttribute called with unexpected values")
+		}
+		return &cip.VerifyUserAttributeOutput{}, nil
+	}
+
+	if err := ts.VerifyUserAttribute(context.Background(), "email", "123456"); err != nil {
+		t.Errorf("VerifyUserAttribute returned an error: %v", err)
+	}
+}
+
+func TestTokenSource_DeleteUserAttributes(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	cognitoMock.deleteUserAttributesHandler = func(duai *cip.DeleteUserAttributesInput) (*cip.DeleteUserAttributesOutput, error) {
+		if len(duai.UserAttributeNames) != 2 {
+			t.Errorf("Unexpected number of attribute names %d", len(duai.UserAttributeNames))
+		}
+		return &cip.DeleteUserAttributesOutput{}, nil
+	}
+
+	if err := ts.DeleteUserAttributes(context.Background(), "custom:a", "custom:b"); err != nil {
+		t.Errorf("DeleteUserAttributes returned an error: %v", err)
+	}
+}
+
+func TestTokenSource_ChangePassword(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	cognitoMock.changePasswordHandler = func(cpi *cip.ChangePasswordInput) (*cip.ChangePasswordOutput, error) {
+		if *cpi.AccessToken != "AccessToken" {
+			t.Error("Unexpected value for AccessToken")
+		}
+		if *cpi.PreviousPassword != "password" || *cpi.ProposedPassword != "newPassword" {
+			t.Error("ChangePassword called with unexpected passwords")
+		}
+		return &cip.ChangePasswordOutput{}, nil
+	}
+
+	if err := ts.ChangePassword(context.Background(), "password", "newPassword"); err != nil {
+		t.Errorf("ChangePassword returned an error: %v", err)
+	}
+
+	if ts.password != "newPassword" {
+		t.Error("Password was not updated")
+	}
+	if ts.config.Password != "password" {
+		t.Error("Config password was modified")
+	}
+}
+
+func TestTokenSource_SetUserMFAPreference(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	cognitoMock.setUserMFAPreferenceHandler = func(sumpi *cip.SetUserMFAPreferenceInput) (*cip.SetUserMFAPreferenceOutput, error) {
+		if sumpi.SMSMfaSettings != nil {
+			t.Error("Expected SMSMfaSettings to be nil")
+		}
+		if !*sumpi.SoftwareTokenMfaSettings.Enabled || !*sumpi.SoftwareTokenMfaSettings.PreferredMfa {
+			t.Error("SoftwareTokenMfaSettings has unexpected value")
+		}
+		return &cip.SetUserMFAPreferenceOutput{}, nil
+	}
+
+	if err := ts.SetUserMFAPreference(context.Background(), nil, &MFAPreference{Enabled: true, Preferred: true}); err != nil {
+		t.Errorf("SetUserMFAPreference returned an error: %v", err)
+	}
+}
+
+func getAuthenticatedTokenSource(cognitoMock *mockCognito) *TokenSource {
+	ts := getTokenSource(cognitoMock)
+	ts.tkn.AccessToken = "AccessToken"
+	ts.tkn.IDToken = "IDToken"
+	ts.tkn.RefreshToken = "RefreshToken"
+	ts.tkn.Expiration = time.Now().Add(1 * time.Hour)
+	return ts
+}
+
+func TestTokenSource_GetUser_Canceled(t *testing.T) {
+	cognitoMock := &mockCognito{}
+	ts := getAuthenticatedTokenSource(cognitoMock)
+
+	cognitoMock.getUserHandler = func(gui *cip.GetUserInput) (*cip.GetUserOutput, error) {
+		t.Error("GetUser should not be called")
+		return nil, nil
+	}
+
+	// The token cannot be acquired while the TokenSource is locked, so the call gives up when ctx is done.
+	ts.lock()
+	defer ts.unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := ts.GetUser(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Unexpected error: %v. Expected: %v", err, context.DeadlineExceeded)
+	}
+}
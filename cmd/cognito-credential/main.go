@@ -0,0 +1,162 @@
+// Command cognito-credential is a credential helper for kubectl, Docker and git, handing out tokens from a Cognito user
+// pool. It reads its configuration and shares its token cache with cognito-token.
+//
+// Usage:
+//
+//	cognito-credential [flags] kubectl
+//	cognito-credential [flags] docker get|store|erase|list
+//	cognito-credential [flags] git get|store|erase
+//
+// kubectl, in the user section of a kubeconfig:
+//
+//	exec:
+//	  apiVersion: client.authentication.k8s.io/v1beta1
+//	  command: cognito-credential
+//	  args: ["kubectl"]
+//
+// Docker looks for a program named docker-credential-<helper>. When invoked through a link with such a name,
+// cognito-credential acts as a Docker credential helper, eg. with a link named docker-credential-cognito:
+//
+//	{"credHelpers": {"registry.example.com": "cognito"}}
+//
+// git:
+//
+//	git config credential.https://git.example.com.helper "!cognito-credential git"
+//
+// Only get hands out credentials. store and erase are accepted and ignored, since the tokens are managed by the user
+// pool.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/larwef/cognito/client"
+	"github.com/larwef/cognito/internal/cli"
+)
+
+const dockerHelperPrefix = "docker-credential-"
+
+type options struct {
+	cli.Config
+	token  string
+	format string
+	action string
+}
+
+func main() {
+	args := os.Args[1:]
+	if strings.HasPrefix(filepath.Base(os.Args[0]), dockerHelperPrefix) {
+		args = append([]string{"docker"}, args...)
+	}
+
+	os.Exit(run(args, os.Getenv, os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, getenv func(string) string, stdin io.Reader, stdout, stderr io.Writer) int {
+	opts, err := parseFlags(args, getenv, stderr)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "cognito-credential: %v\n", err)
+		return 2
+	}
+
+	if err := write(context.Background(), opts, stdin, stdout); err != nil {
+		fmt.Fprintf(stderr, "cognito-credential: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func parseFlags(args []string, getenv func(string) string, stderr io.Writer) (*options, error) {
+	opts := &options{}
+
+	fs := flag.NewFlagSet("cognito-credential", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: cognito-credential [flags] kubectl|docker|git [get|store|erase]")
+		fs.PrintDefaults()
+	}
+	opts.RegisterFlags(fs, getenv)
+	fs.StringVar(&opts.token, "token", "id", "token to hand out: id or access")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if opts.token != "id" && opts.token != "access" {
+		return nil, fmt.Errorf("unknown token: %s. Use id or access", opts.token)
+	}
+
+	switch fs.NArg() {
+	case 1:
+		opts.format = fs.Arg(0)
+		opts.action = "get"
+	case 2:
+		opts.format, opts.action = fs.Arg(0), fs.Arg(1)
+	default:
+		return nil, errors.New("expected a format and an optional action")
+	}
+
+	switch opts.format {
+	case "kubectl", "docker", "git":
+	default:
+		return nil, fmt.Errorf("unknown format: %s. Use kubectl, docker or git", opts.format)
+	}
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+func write(ctx context.Context, opts *options, stdin io.Reader, stdout io.Writer) error {
+	switch opts.action {
+	case "get":
+	case "store", "erase":
+		// Tokens are managed by the user pool. Drain the input so the caller does not block.
+		_, err := io.Copy(ioutil.Discard, stdin)
+		return err
+	case "list":
+		if opts.format == "docker" {
+			_, err := io.WriteString(stdout, "{}\n")
+			return err
+		}
+		fallthrough
+	default:
+		return fmt.Errorf("unknown action: %s", opts.action)
+	}
+
+	tkn, err := opts.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	helper := &client.CredentialHelper{
+		Source:   client.StaticTokenSource(tkn),
+		Username: opts.Username,
+	}
+	if opts.token == "access" {
+		helper.SelectToken = client.SelectAccessToken
+	}
+
+	switch opts.format {
+	case "docker":
+		return helper.DockerCredential(ctx, stdin, stdout)
+	case "git":
+		return helper.GitCredential(ctx, stdin, stdout)
+	default:
+		return helper.ExecCredential(ctx, stdout)
+	}
+}
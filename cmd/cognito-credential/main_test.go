@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/larwef/cognito/cognitotest"
+)
+
+func newCognito(t *testing.T) *cognitotest.Server {
+	pool, err := cognitotest.NewUserPool("", "")
+	if err != nil {
+		t.Fatalf("error creating user pool: %v", err)
+	}
+
+	if err := pool.AddUser(cognitotest.User{Username: "testUser", Password: "Password123!"}); err != nil {
+		t.Fatalf("error adding user: %v", err)
+	}
+
+	return cognitotest.NewServer(pool)
+}
+
+func newEnv(cognito *cognitotest.Server) func(string) string {
+	env := map[string]string{
+		"COGNITO_USERPOOL_ID": cognito.UserPool().ID(),
+		"COGNITO_CLIENT_ID":   cognito.UserPool().ClientID(),
+		"COGNITO_USERNAME":    "testUser",
+		"COGNITO_PASSWORD":    "Password123!",
+		"COGNITO_ENDPOINT":    cognito.URL,
+	}
+
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func TestRun(t *testing.T) {
+	cognito := newCognito(t)
+	defer cognito.Close()
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		expected string
+	}{
+		{"kubectl", []string{"-no-cache", "kubectl"}, "", `"kind":"ExecCredential"`},
+		{"docker", []string{"-no-cache", "docker", "get"}, "registry.example.com", `"Username":"testUser"`},
+		{"docker list", []string{"-no-cache", "docker", "list"}, "", "{}"},
+		{"git", []string{"-no-cache", "git", "get"}, "protocol=https\nhost=git.example.com\n\n", "password_expiry_utc="},
+		{"git store", []string{"-no-cache", "git", "store"}, "protocol=https\nhost=git.example.com\n\n", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(test.args, newEnv(cognito), strings.NewReader(test.stdin), &stdout, &stderr); code != 0 {
+				t.Fatalf("Unexpected exit code: %d. Stderr: %s", code, stderr.String())
+			}
+
+			if !strings.Contains(stdout.String(), test.expected) {
+				t.Errorf("Expected output to contain %q. Got: %s", test.expected, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRun_AccessToken(t *testing.T) {
+	cognito := newCognito(t)
+	defer cognito.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-no-cache", "-token", "access", "kubectl"}, newEnv(cognito), strings.NewReader(""), &stdout, &stderr); code != 0 {
+		t.Fatalf("Unexpected exit code: %d. Stderr: %s", code, stderr.String())
+	}
+
+	var cred struct {
+		Status struct {
+			Token string `json:"token"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &cred); err != nil {
+		t.Fatalf("error unmarshalling output: %v", err)
+	}
+
+	if cred.Status.Token == "" {
+		t.Error("Expected a token")
+	}
+}
+
+func TestRun_InvalidArgs(t *testing.T) {
+	cognito := newCognito(t)
+	defer cognito.Close()
+
+	for _, args := range [][]string{{}, {"ssh"}, {"-token", "refresh", "kubectl"}} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, newEnv(cognito), strings.NewReader(""), &stdout, &stderr); code != 2 {
+			t.Errorf("Unexpected exit code: %d for %v. Expected: %d", code, args, 2)
+		}
+	}
+}
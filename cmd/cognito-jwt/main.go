@@ -0,0 +1,220 @@
+// Command cognito-jwt decodes a JWT and optionally verifies it, without sending it anywhere but to the issuer for its
+// public keys. The token is read from the first argument or from stdin.
+//
+// The header and claims are printed as JSON with the time claims exp, iat, nbf and auth_time shown as RFC 3339
+// timestamps. With -issuer the token is verified against the JWKS of the issuer. With -jwks it is verified against a
+// local JWKS file, and the issuer is only checked if -issuer is given too.
+//
+// The exit code tells why verification failed:
+//
+//	0 the token was decoded, and verified if requested
+//	1 the token could not be decoded or the public keys could not be fetched
+//	2 invalid usage
+//	3 the token has expired
+//	4 the issuer does not match
+//	5 the signature or signing algorithm is invalid
+//	6 the key ID of the token is not in the JWKS
+//	7 the token is not valid yet, or was issued in the future
+//
+// Usage:
+//
+//	cognito-jwt [flags] [token]
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/larwef/cognito/verifier"
+)
+
+// Exit codes.
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitExpired
+	exitIssuer
+	exitSignature
+	exitUnknownKey
+	exitNotYetValid
+)
+
+var timeClaims = []string{"exp", "iat", "nbf", "auth_time"}
+
+type options struct {
+	issuer string
+	jwks   string
+	at     time.Time
+	token  string
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	opts, err := parseFlags(args, stdin, stderr)
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "cognito-jwt: %v\n", err)
+		return exitUsage
+	}
+
+	token, err := verifier.ParseJWT(opts.token)
+	if err != nil {
+		fmt.Fprintf(stderr, "cognito-jwt: %v\n", err)
+		return exitError
+	}
+
+	if err := printToken(stdout, token); err != nil {
+		fmt.Fprintf(stderr, "cognito-jwt: %v\n", err)
+		return exitError
+	}
+
+	if opts.issuer == "" && opts.jwks == "" {
+		fmt.Fprintln(stderr, "cognito-jwt: signature not verified. Use -issuer or -jwks to verify")
+		return exitOK
+	}
+
+	if err := verify(opts); err != nil {
+		fmt.Fprintf(stderr, "cognito-jwt: invalid token: %v\n", err)
+		return exitCode(err)
+	}
+
+	fmt.Fprintln(stderr, "cognito-jwt: token verified")
+	return exitOK
+}
+
+func parseFlags(args []string, stdin io.Reader, stderr io.Writer) (*options, error) {
+	opts := &options{}
+	var at string
+
+	fs := flag.NewFlagSet("cognito-jwt", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: cognito-jwt [flags] [token]")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.issuer, "issuer", "", "verify the token against the JWKS of this issuer, eg. https://cognito-idp.<region>.amazonaws.com/<pool id>")
+	fs.StringVar(&opts.jwks, "jwks", "", "verify the token against this local JWKS file")
+	fs.StringVar(&at, "time", "", "verify expiry at this RFC 3339 time instead of now")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if at != "" {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time: %v", err)
+		}
+		opts.at = t
+	}
+
+	switch fs.NArg() {
+	case 0:
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("error reading token: %v", err)
+		}
+		opts.token = line
+	case 1:
+		opts.token = fs.Arg(0)
+	default:
+		return nil, errors.New("too many arguments")
+	}
+
+	opts.token = strings.TrimSpace(opts.token)
+	if opts.token == "" {
+		return nil, errors.New("no token given")
+	}
+
+	return opts, nil
+}
+
+func printToken(w io.Writer, token *verifier.JWTToken) error {
+	claims := make(map[string]interface{}, len(token.Claims))
+	for k, v := range token.Claims {
+		claims[k] = v
+	}
+
+	for _, name := range timeClaims {
+		if v, ok := claims[name].(float64); ok {
+			claims[name] = fmt.Sprintf("%s (%d)", time.Unix(int64(v), 0).UTC().Format(time.RFC3339), int64(v))
+		}
+	}
+
+	b, err := json.MarshalIndent(struct {
+		Header verifier.JWTHeader     `json:"header"`
+		Claims map[string]interface{} `json:"claims"`
+	}{token.Header, claims}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling token: %v", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+func verify(opts *options) error {
+	jv := &verifier.JWTVerifier{Issuer: opts.issuer}
+
+	if opts.jwks != "" {
+		b, err := ioutil.ReadFile(opts.jwks)
+		if err != nil {
+			return fmt.Errorf("error reading JWKS: %v", err)
+		}
+		jv.Client = &http.Client{Transport: fileTransport(b)}
+		// The keys come from the file, so the issuer is only checked if it is given.
+		jv.SkipIssuerCheck = opts.issuer == ""
+	}
+
+	if !opts.at.IsZero() {
+		jv.Clock = verifier.ClockFunc(func() time.Time { return opts.at })
+	}
+
+	_, err := jv.Parse(opts.token)
+	return err
+}
+
+func exitCode(err error) int {
+	switch err {
+	case verifier.ErrTokenExpired:
+		return exitExpired
+	case verifier.ErrIssuerDoesntMatch:
+		return exitIssuer
+	case verifier.ErrInvalidSignature, verifier.ErrUnexpectedAlg:
+		return exitSignature
+	case verifier.ErrMissingPublicKey:
+		return exitUnknownKey
+	case verifier.ErrTokenNotYetValid, verifier.ErrTokenUsedBeforeIssued:
+		return exitNotYetValid
+	default:
+		return exitError
+	}
+}
+
+// fileTransport answers every request with the JWKS read from a local file.
+type fileTransport []byte
+
+func (t fileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(t)),
+		Request:    req,
+	}, nil
+}
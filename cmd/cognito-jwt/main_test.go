@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/larwef/cognito/client"
+	"github.com/larwef/cognito/cognitotest"
+)
+
+func newToken(t *testing.T, pool *cognitotest.UserPool) string {
+	if err := pool.AddUser(cognitotest.User{Username: "testUser", Password: "Password123!"}); err != nil {
+		t.Fatalf("error adding user: %v", err)
+	}
+
+	ts, err := client.NewTokenSource(&client.Config{
+		UserpoolID:       pool.ID(),
+		ClientID:         pool.ClientID(),
+		Username:         "testUser",
+		Password:         "Password123!",
+		IdentityProvider: pool,
+	})
+	if err != nil {
+		t.Fatalf("error creating token source: %v", err)
+	}
+
+	tkn, err := ts.GetToken()
+	if err != nil {
+		t.Fatalf("error getting token: %v", err)
+	}
+
+	return tkn.IDToken
+}
+
+func writeJWKS(t *testing.T, dir string, pool *cognitotest.UserPool) string {
+	path := filepath.Join(dir, pool.ID()+".json")
+	if err := ioutil.WriteFile(path, pool.JWKS(), 0600); err != nil {
+		t.Fatalf("error writing JWKS: %v", err)
+	}
+
+	return path
+}
+
+func TestRun(t *testing.T) {
+	pool, err := cognitotest.NewUserPool("", "")
+	if err != nil {
+		t.Fatalf("error creating user pool: %v", err)
+	}
+	otherPool, err := cognitotest.NewUserPool("eu-west-1_other", "")
+	if err != nil {
+		t.Fatalf("error creating user pool: %v", err)
+	}
+
+	cognito := cognitotest.NewServer(pool)
+	defer cognito.Close()
+
+	token := newToken(t, pool)
+
+	dir, err := ioutil.TempDir("", "cognito-jwt")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	jwks := writeJWKS(t, dir, pool)
+	otherJWKS := writeJWKS(t, dir, otherPool)
+
+	split := strings.Split(token, ".")
+	tampered := split[0] + "." + split[1] + "." + split[0]
+	expired := time.Now().Add(2 * pool.TokenValidity).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected int
+	}{
+		{"decode", []string{token}, exitOK},
+		{"issuer", []string{"-issuer", cognito.Issuer(), token}, exitOK},
+		{"jwks", []string{"-jwks", jwks, token}, exitOK},
+		{"expired", []string{"-jwks", jwks, "-time", expired, token}, exitExpired},
+		{"wrong issuer", []string{"-jwks", jwks, "-issuer", "https://example.com", token}, exitIssuer},
+		{"bad signature", []string{"-jwks", jwks, tampered}, exitSignature},
+		{"forged expired", []string{"-jwks", jwks, "-time", expired, tampered}, exitSignature},
+		{"forged issuer", []string{"-jwks", jwks, "-issuer", "https://example.com", tampered}, exitSignature},
+		{"unknown kid", []string{"-jwks", otherJWKS, token}, exitUnknownKey},
+		{"malformed", []string{"notAToken"}, exitError},
+		{"usage", []string{"-time", "yesterday", token}, exitUsage},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(test.args, strings.NewReader(""), &stdout, &stderr); code != test.expected {
+				t.Errorf("Unexpected exit code: %d. Expected: %d. Stderr: %s", code, test.expected, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun_Output(t *testing.T) {
+	pool, err := cognitotest.NewUserPool("", "")
+	if err != nil {
+		t.Fatalf("error creating user pool: %v", err)
+	}
+
+	token := newToken(t, pool)
+
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, strings.NewReader("Bearer "+token+"\n"), &stdout, &stderr); code != exitOK {
+		t.Fatalf("Unexpected exit code: %d. Stderr: %s", code, stderr.String())
+	}
+
+	var out struct {
+		Header map[string]interface{} `json:"header"`
+		Claims map[string]interface{} `json:"claims"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		t.Fatalf("error unmarshalling output: %v. Output: %s", err, stdout.String())
+	}
+
+	if out.Header["alg"] != "RS256" {
+		t.Errorf("Unexpected value: %v for alg", out.Header["alg"])
+	}
+
+	exp, ok := out.Claims["exp"].(string)
+	if !ok || !strings.Contains(exp, "T") {
+		t.Errorf("Expected exp as a timestamp. Got: %v", out.Claims["exp"])
+	}
+
+	if out.Claims["cognito:username"] != "testUser" {
+		t.Errorf("Unexpected value: %v for cognito:username", out.Claims["cognito:username"])
+	}
+}
@@ -0,0 +1,187 @@
+// Command cognito-token authenticates with a Cognito user pool and prints the ID token, the access token or the full
+// token set. If a command is given after the flags, it is run with the token in an environment variable instead.
+//
+// The user pool, client, username and password are read from flags or from the environment variables
+// COGNITO_USERPOOL_ID, COGNITO_CLIENT_ID, COGNITO_USERNAME and COGNITO_PASSWORD. The password can only be given in the
+// environment so it does not show up in the process list.
+//
+// Tokens are cached in the user cache directory between invocations. A cached token is used until it expires, and is
+// then refreshed with the cached refresh token. The password is only needed when there is no usable refresh token.
+//
+// Usage:
+//
+//	cognito-token [flags]
+//	cognito-token [flags] -- command [args...]
+//
+// Eg:
+//
+//	curl -H "Authorization: $(cognito-token)" https://api.example.com
+//	cognito-token -token access -- sh -c 'curl -H "Authorization: Bearer $COGNITO_TOKEN" https://api.example.com'
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/larwef/cognito/client"
+	"github.com/larwef/cognito/internal/cli"
+)
+
+type options struct {
+	cli.Config
+	token   string
+	json    bool
+	env     string
+	command []string
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Getenv, os.Stdout, os.Stderr))
+}
+
+func run(args []string, getenv func(string) string, stdout, stderr io.Writer) int {
+	opts, err := parseFlags(args, getenv, stderr)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "cognito-token: %v\n", err)
+		return 2
+	}
+
+	tkn, err := opts.Token(context.Background())
+	if err != nil {
+		fmt.Fprintf(stderr, "cognito-token: %v\n", err)
+		return 1
+	}
+
+	if len(opts.command) > 0 {
+		return runCommand(opts, tkn, stdout, stderr)
+	}
+
+	if err := printToken(stdout, opts, tkn); err != nil {
+		fmt.Fprintf(stderr, "cognito-token: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func parseFlags(args []string, getenv func(string) string, stderr io.Writer) (*options, error) {
+	opts := &options{}
+
+	fs := flag.NewFlagSet("cognito-token", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: cognito-token [flags] [-- command [args...]]")
+		fs.PrintDefaults()
+	}
+	opts.RegisterFlags(fs, getenv)
+	fs.StringVar(&opts.token, "token", "id", "token to print or export: id, access or all")
+	fs.BoolVar(&opts.json, "json", false, "print JSON instead of the raw token")
+	fs.StringVar(&opts.env, "env", "COGNITO_TOKEN", "environment variable holding the token for the command")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	opts.command = fs.Args()
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch opts.token {
+	case "id", "access":
+	case "all":
+		if len(opts.command) > 0 {
+			return nil, errors.New("-token all cannot be used with a command")
+		}
+	default:
+		return nil, fmt.Errorf("unknown token: %s. Use id, access or all", opts.token)
+	}
+
+	return opts, nil
+}
+
+func selectToken(opts *options, tkn *client.Token) string {
+	if opts.token == "access" {
+		return tkn.AccessToken
+	}
+
+	return tkn.IDToken
+}
+
+func printToken(w io.Writer, opts *options, tkn *client.Token) error {
+	if opts.token == "all" {
+		b, err := tkn.Persist()
+		if err != nil {
+			return fmt.Errorf("error marshalling token: %v", err)
+		}
+
+		var out bytes.Buffer
+		if err := json.Indent(&out, b, "", "  "); err != nil {
+			return fmt.Errorf("error marshalling token: %v", err)
+		}
+		out.WriteByte('\n')
+
+		_, err = out.WriteTo(w)
+		return err
+	}
+
+	if opts.json {
+		return json.NewEncoder(w).Encode(struct {
+			Token      string    `json:"token"`
+			TokenType  string    `json:"token_type,omitempty"`
+			Expiration time.Time `json:"expiration"`
+		}{selectToken(opts, tkn), tkn.TokenType, tkn.Expiration})
+	}
+
+	_, err := fmt.Fprintln(w, selectToken(opts, tkn))
+	return err
+}
+
+// runCommand runs the command with the token in the environment and returns its exit code. The password is removed
+// from the environment of the command.
+func runCommand(opts *options, tkn *client.Token, stdout, stderr io.Writer) int {
+	cmd := exec.Command(opts.command[0], opts.command[1:]...)
+	cmd.Env = append(commandEnv(os.Environ()), opts.env+"="+selectToken(opts, tkn))
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			// ExitError.ExitCode needs Go 1.12, so the status is read from the WaitStatus.
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+				return status.ExitStatus()
+			}
+			return 1
+		}
+		fmt.Fprintf(stderr, "cognito-token: error running command: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// commandEnv returns environ without COGNITO_PASSWORD.
+func commandEnv(environ []string) []string {
+	env := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		if !strings.HasPrefix(kv, "COGNITO_PASSWORD=") {
+			env = append(env, kv)
+		}
+	}
+
+	return env
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/larwef/cognito/client"
+	"github.com/larwef/cognito/cognitotest"
+)
+
+func newCognito(t *testing.T) *cognitotest.Server {
+	pool, err := cognitotest.NewUserPool("", "")
+	if err != nil {
+		t.Fatalf("error creating user pool: %v", err)
+	}
+
+	if err := pool.AddUser(cognitotest.User{Username: "testUser", Password: "Password123!"}); err != nil {
+		t.Fatalf("error adding user: %v", err)
+	}
+
+	return cognitotest.NewServer(pool)
+}
+
+func newEnv(cognito *cognitotest.Server, password string) func(string) string {
+	env := map[string]string{
+		"COGNITO_USERPOOL_ID": cognito.UserPool().ID(),
+		"COGNITO_CLIENT_ID":   cognito.UserPool().ClientID(),
+		"COGNITO_USERNAME":    "testUser",
+		"COGNITO_PASSWORD":    password,
+		"COGNITO_ENDPOINT":    cognito.URL,
+	}
+
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func TestRun(t *testing.T) {
+	cognito := newCognito(t)
+	defer cognito.Close()
+
+	cache, cleanup := tempCache(t)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-cache", cache, "-token", "all"}, newEnv(cognito, "Password123!"), &stdout, &stderr); code != 0 {
+		t.Fatalf("Unexpected exit code: %d. Stderr: %s", code, stderr.String())
+	}
+
+	var tkn client.Token
+	if err := json.Unmarshal(stdout.Bytes(), &tkn); err != nil {
+		t.Fatalf("error unmarshalling output: %v", err)
+	}
+
+	if tkn.IDToken == "" || tkn.AccessToken == "" || tkn.RefreshToken == "" {
+		t.Errorf("Expected all tokens in output. Got: %s", stdout.String())
+	}
+
+	// The cached token is used without a password.
+	stdout.Reset()
+	if code := run([]string{"-cache", cache, "-token", "access"}, newEnv(cognito, ""), &stdout, &stderr); code != 0 {
+		t.Fatalf("Unexpected exit code: %d. Stderr: %s", code, stderr.String())
+	}
+
+	if strings.TrimSpace(stdout.String()) != tkn.AccessToken {
+		t.Errorf("Unexpected value: %s. Expected: %s", stdout.String(), tkn.AccessToken)
+	}
+}
+
+func TestRun_Refresh(t *testing.T) {
+	cognito := newCognito(t)
+	defer cognito.Close()
+
+	cache, cleanup := tempCache(t)
+	defer cleanup()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-cache", cache}, newEnv(cognito, "Password123!"), &stdout, &stderr); code != 0 {
+		t.Fatalf("Unexpected exit code: %d. Stderr: %s", code, stderr.String())
+	}
+
+	// Expire the cached token so the refresh token has to be used.
+	b, err := json.Marshal(map[string]interface{}{"refresh_token": mustLoad(t, cache).RefreshToken, "expiration": "2006-01-02T15:04:05Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(cache, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"-cache", cache}, newEnv(cognito, ""), &stdout, &stderr); code != 0 {
+		t.Fatalf("Unexpected exit code: %d. Stderr: %s", code, stderr.String())
+	}
+
+	if strings.TrimSpace(stdout.String()) == "" {
+		t.Error("Expected a token")
+	}
+
+	if mustLoad(t, cache).RefreshToken == "" {
+		t.Error("Expected refresh token to be kept in the cache")
+	}
+}
+
+func TestRun_NoPassword(t *testing.T) {
+	cognito := newCognito(t)
+	defer cognito.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-no-cache"}, newEnv(cognito, ""), &stdout, &stderr); code != 1 {
+		t.Errorf("Unexpected exit code: %d. Expected: %d", code, 1)
+	}
+}
+
+func TestRun_InvalidFlags(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	getenv := func(string) string { return "" }
+
+	if code := run([]string{"-no-cache"}, getenv, &stdout, &stderr); code != 2 {
+		t.Errorf("Unexpected exit code: %d. Expected: %d", code, 2)
+	}
+
+	if code := run([]string{"-userpool", "eu-west-1_pool", "-client-id", "id", "-username", "user", "-token", "refresh"}, getenv, &stdout, &stderr); code != 2 {
+		t.Errorf("Unexpected exit code: %d. Expected: %d", code, 2)
+	}
+}
+
+func TestRun_Command(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+
+	cognito := newCognito(t)
+	defer cognito.Close()
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"-no-cache", "-env", "TOKEN", "--", "sh", "-c", `printf %s "$TOKEN"; exit 3`}
+	if code := run(args, newEnv(cognito, "Password123!"), &stdout, &stderr); code != 3 {
+		t.Fatalf("Unexpected exit code: %d. Expected: %d. Stderr: %s", code, 3, stderr.String())
+	}
+
+	if strings.Count(stdout.String(), ".") != 2 {
+		t.Errorf("Expected a JWT in the environment. Got: %s", stdout.String())
+	}
+}
+
+func mustLoad(t *testing.T, path string) *client.Token {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading cache: %v", err)
+	}
+
+	var tkn client.Token
+	if err := json.Unmarshal(b, &tkn); err != nil {
+		t.Fatalf("error unmarshalling cache: %v", err)
+	}
+
+	return &tkn
+}
+
+func tempCache(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "cognito-token")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+
+	return filepath.Join(dir, "token.json"), func() { os.RemoveAll(dir) }
+}
+
+func TestRun_CommandWithoutPassword(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+
+	cognito := newCognito(t)
+	defer cognito.Close()
+
+	password, set := os.LookupEnv("COGNITO_PASSWORD")
+	os.Setenv("COGNITO_PASSWORD", "Password123!")
+	defer func() {
+		if set {
+			os.Setenv("COGNITO_PASSWORD", password)
+		} else {
+			os.Unsetenv("COGNITO_PASSWORD")
+		}
+	}()
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"-no-cache", "--", "sh", "-c", `printf %s "${COGNITO_PASSWORD-unset}"`}
+	if code := run(args, newEnv(cognito, "Password123!"), &stdout, &stderr); code != 0 {
+		t.Fatalf("Unexpected exit code: %d. Stderr: %s", code, stderr.String())
+	}
+
+	if stdout.String() != "unset" {
+		t.Errorf("Expected COGNITO_PASSWORD to be removed from the environment. Got: %s", stdout.String())
+	}
+}
@@ -0,0 +1,395 @@
+package cognitotest
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+)
+
+const (
+	secretBlockSize = 1024
+	timestampFormat = "Mon Jan 2 15:04:05 MST 2006"
+)
+
+// challengeSession holds the state of a challenge identified by the Session returned to the client.
+type challengeSession struct {
+	username      string
+	challengeName string
+}
+
+func errNotAuthorized(message string) error {
+	return awserr.New(cip.ErrCodeNotAuthorizedException, message, nil)
+}
+
+func errInvalidParameter(format string, a ...interface{}) error {
+	return awserr.New(cip.ErrCodeInvalidParameterException, fmt.Sprintf(format, a...), nil)
+}
+
+func checkContext(ctx aws.Context) error {
+	if ctx == nil {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return awserr.New(request.CanceledErrorCode, "request context canceled", ctx.Err())
+	default:
+		return nil
+	}
+}
+
+// InitiateAuth implements CognitoIdentityProviderAPI.
+func (up *UserPool) InitiateAuth(input *cip.InitiateAuthInput) (*cip.InitiateAuthOutput, error) {
+	return up.InitiateAuthWithContext(aws.BackgroundContext(), input)
+}
+
+// InitiateAuthWithContext implements CognitoIdentityProviderAPI. The USER_SRP_AUTH, REFRESH_TOKEN_AUTH and
+// REFRESH_TOKEN flows are supported.
+func (up *UserPool) InitiateAuthWithContext(ctx aws.Context, input *cip.InitiateAuthInput, _ ...request.Option) (*cip.InitiateAuthOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := up.checkClientID(input.ClientId); err != nil {
+		return nil, err
+	}
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	switch aws.StringValue(input.AuthFlow) {
+	case cip.AuthFlowTypeUserSrpAuth:
+		return up.initiateSrpAuth(input.AuthParameters)
+	case cip.AuthFlowTypeRefreshTokenAuth, cip.AuthFlowTypeRefreshToken:
+		username, exists := up.refreshTokens[aws.StringValue(input.AuthParameters["REFRESH_TOKEN"])]
+		if !exists {
+			return nil, errNotAuthorized("Invalid Refresh Token")
+		}
+
+		res, err := up.issueTokens(up.users[username], false)
+		if err != nil {
+			return nil, err
+		}
+
+		return &cip.InitiateAuthOutput{AuthenticationResult: res, ChallengeParameters: map[string]*string{}}, nil
+	default:
+		return nil, errInvalidParameter("Unsupported AuthFlow: %s", aws.StringValue(input.AuthFlow))
+	}
+}
+
+func (up *UserPool) initiateSrpAuth(params map[string]*string) (*cip.InitiateAuthOutput, error) {
+	username := aws.StringValue(params["USERNAME"])
+	user, exists := up.users[username]
+	if !exists {
+		return nil, awserr.New(cip.ErrCodeUserNotFoundException, "User does not exist.", nil)
+	}
+
+	xA, ok := big.NewInt(0).SetString(aws.StringValue(params["SRP_A"]), 16)
+	if !ok {
+		return nil, errInvalidParameter("Invalid SRP_A")
+	}
+
+	session, err := NewSRPServer(up.userpoolName(), username, user.salt, user.verifier, xA, nil)
+	if err != nil {
+		return nil, errInvalidParameter("%v", err)
+	}
+
+	secretBlock := make([]byte, secretBlockSize)
+	if _, err := rand.Read(secretBlock); err != nil {
+		return nil, fmt.Errorf("error generating secret block: %v", err)
+	}
+	encodedSecretBlock := base64.StdEncoding.EncodeToString(secretBlock)
+	up.srpSessions[encodedSecretBlock] = session
+
+	return &cip.InitiateAuthOutput{
+		ChallengeName: aws.String(cip.ChallengeNameTypePasswordVerifier),
+		ChallengeParameters: map[string]*string{
+			"SALT":            aws.String(session.Salt().Text(16)),
+			"SECRET_BLOCK":    aws.String(encodedSecretBlock),
+			"SRP_B":           aws.String(session.B().Text(16)),
+			"USERNAME":        aws.String(username),
+			"USER_ID_FOR_SRP": aws.String(username),
+		},
+	}, nil
+}
+
+// RespondToAuthChallenge implements CognitoIdentityProviderAPI.
+func (up *UserPool) RespondToAuthChallenge(input *cip.RespondToAuthChallengeInput) (*cip.RespondToAuthChallengeOutput, error) {
+	return up.RespondToAuthChallengeWithContext(aws.BackgroundContext(), input)
+}
+
+// RespondToAuthChallengeWithContext implements CognitoIdentityProviderAPI. The PASSWORD_VERIFIER,
+// NEW_PASSWORD_REQUIRED, SOFTWARE_TOKEN_MFA and SMS_MFA challenges are supported.
+func (up *UserPool) RespondToAuthChallengeWithContext(ctx aws.Context, input *cip.RespondToAuthChallengeInput, _ ...request.Option) (*cip.RespondToAuthChallengeOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := up.checkClientID(input.ClientId); err != nil {
+		return nil, err
+	}
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	challengeName := aws.StringValue(input.ChallengeName)
+	responses := input.ChallengeResponses
+	if challengeName == cip.ChallengeNameTypePasswordVerifier {
+		return up.respondPasswordVerifier(responses)
+	}
+
+	session, exists := up.sessions[aws.StringValue(input.Session)]
+	if !exists || session.challengeName != challengeName {
+		return nil, errNotAuthorized("Invalid session for the user.")
+	}
+	user := up.users[session.username]
+
+	switch challengeName {
+	case cip.ChallengeNameTypeNewPasswordRequired:
+		newPassword := aws.StringValue(responses["NEW_PASSWORD"])
+		if newPassword == "" {
+			return nil, errInvalidParameter("Missing required parameter NEW_PASSWORD")
+		}
+
+		delete(up.sessions, aws.StringValue(input.Session))
+		if err := up.setPassword(user, newPassword); err != nil {
+			return nil, err
+		}
+		user.Status = cip.UserStatusTypeConfirmed
+
+		return up.nextStep(user)
+	case cip.ChallengeNameTypeSoftwareTokenMfa, cip.ChallengeNameTypeSmsMfa:
+		code := aws.StringValue(responses[challengeName+"_CODE"])
+		if !hmac.Equal([]byte(code), []byte(user.MFACode)) {
+			return nil, awserr.New(cip.ErrCodeCodeMismatchException, "Invalid code received for user", nil)
+		}
+
+		delete(up.sessions, aws.StringValue(input.Session))
+		res, err := up.issueTokens(user, true)
+		if err != nil {
+			return nil, err
+		}
+
+		return &cip.RespondToAuthChallengeOutput{AuthenticationResult: res, ChallengeParameters: map[string]*string{}}, nil
+	default:
+		return nil, errInvalidParameter("Unsupported ChallengeName: %s", challengeName)
+	}
+}
+
+func (up *UserPool) respondPasswordVerifier(responses map[string]*string) (*cip.RespondToAuthChallengeOutput, error) {
+	encodedSecretBlock := aws.StringValue(responses["PASSWORD_CLAIM_SECRET_BLOCK"])
+	session, exists := up.srpSessions[encodedSecretBlock]
+	if !exists {
+		return nil, errNotAuthorized("Invalid secret block.")
+	}
+	delete(up.srpSessions, encodedSecretBlock)
+
+	if aws.StringValue(responses["USERNAME"]) != session.username {
+		return nil, errNotAuthorized("Incorrect username or password.")
+	}
+
+	timestamp := aws.StringValue(responses["TIMESTAMP"])
+	if _, err := time.Parse(timestampFormat, timestamp); err != nil {
+		return nil, errInvalidParameter("TIMESTAMP format should be EEE MMM d HH:mm:ss z yyyy in english.")
+	}
+
+	secretBlock, _ := base64.StdEncoding.DecodeString(encodedSecretBlock)
+	signature := aws.StringValue(responses["PASSWORD_CLAIM_SIGNATURE"])
+	if err := session.VerifyClaim(secretBlock, timestamp, signature); err != nil {
+		return nil, errNotAuthorized("Incorrect username or password.")
+	}
+
+	user, exists := up.users[session.username]
+	if !exists {
+		return nil, awserr.New(cip.ErrCodeUserNotFoundException, "User does not exist.", nil)
+	}
+
+	return up.nextStep(user)
+}
+
+// nextStep returns the next challenge for the user, or tokens if there are no challenges left.
+func (up *UserPool) nextStep(user *User) (*cip.RespondToAuthChallengeOutput, error) {
+	var challengeName string
+	parameters := map[string]*string{"USER_ID_FOR_SRP": aws.String(user.Username)}
+	switch {
+	case user.Status == cip.UserStatusTypeForceChangePassword:
+		challengeName = cip.ChallengeNameTypeNewPasswordRequired
+		attributes, err := json.Marshal(user.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling user attributes: %v", err)
+		}
+		parameters["requiredAttributes"] = aws.String("[]")
+		parameters["userAttributes"] = aws.String(string(attributes))
+	case user.mfaEnabled():
+		challengeName = cip.ChallengeNameTypeSoftwareTokenMfa
+	default:
+		res, err := up.issueTokens(user, true)
+		if err != nil {
+			return nil, err
+		}
+
+		return &cip.RespondToAuthChallengeOutput{AuthenticationResult: res, ChallengeParameters: map[string]*string{}}, nil
+	}
+
+	session, err := randomString(128)
+	if err != nil {
+		return nil, err
+	}
+	up.sessions[session] = &challengeSession{username: user.Username, challengeName: challengeName}
+
+	return &cip.RespondToAuthChallengeOutput{
+		ChallengeName:       aws.String(challengeName),
+		ChallengeParameters: parameters,
+		Session:             aws.String(session),
+	}, nil
+}
+
+// ChangePassword implements CognitoIdentityProviderAPI.
+func (up *UserPool) ChangePassword(input *cip.ChangePasswordInput) (*cip.ChangePasswordOutput, error) {
+	return up.ChangePasswordWithContext(aws.BackgroundContext(), input)
+}
+
+// ChangePasswordWithContext implements CognitoIdentityProviderAPI.
+func (up *UserPool) ChangePasswordWithContext(ctx aws.Context, input *cip.ChangePasswordInput, _ ...request.Option) (*cip.ChangePasswordOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	user, err := up.authorize(input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal([]byte(aws.StringValue(input.PreviousPassword)), []byte(user.Password)) {
+		return nil, errNotAuthorized("Incorrect username or password.")
+	}
+
+	if err := up.setPassword(user, aws.StringValue(input.ProposedPassword)); err != nil {
+		return nil, err
+	}
+
+	return &cip.ChangePasswordOutput{}, nil
+}
+
+// GetUser implements CognitoIdentityProviderAPI.
+func (up *UserPool) GetUser(input *cip.GetUserInput) (*cip.GetUserOutput, error) {
+	return up.GetUserWithContext(aws.BackgroundContext(), input)
+}
+
+// GetUserWithContext implements CognitoIdentityProviderAPI.
+func (up *UserPool) GetUserWithContext(ctx aws.Context, input *cip.GetUserInput, _ ...request.Option) (*cip.GetUserOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	user, err := up.authorize(input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(user.Attributes))
+	for name := range user.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	attributes := []*cip.AttributeType{{Name: aws.String("sub"), Value: aws.String(user.sub)}}
+	for _, name := range names {
+		attributes = append(attributes, &cip.AttributeType{Name: aws.String(name), Value: aws.String(user.Attributes[name])})
+	}
+
+	output := &cip.GetUserOutput{
+		UserAttributes: attributes,
+		Username:       aws.String(user.Username),
+	}
+	if user.mfaEnabled() {
+		output.PreferredMfaSetting = aws.String(cip.ChallengeNameTypeSoftwareTokenMfa)
+		output.UserMFASettingList = []*string{aws.String(cip.ChallengeNameTypeSoftwareTokenMfa)}
+	}
+
+	return output, nil
+}
+
+// GlobalSignOut implements CognitoIdentityProviderAPI.
+func (up *UserPool) GlobalSignOut(input *cip.GlobalSignOutInput) (*cip.GlobalSignOutOutput, error) {
+	return up.GlobalSignOutWithContext(aws.BackgroundContext(), input)
+}
+
+// GlobalSignOutWithContext implements CognitoIdentityProviderAPI. All access and refresh tokens issued to the user are
+// invalidated.
+func (up *UserPool) GlobalSignOutWithContext(ctx aws.Context, input *cip.GlobalSignOutInput, _ ...request.Option) (*cip.GlobalSignOutOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	user, err := up.authorize(input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	up.signOut(user.Username)
+
+	return &cip.GlobalSignOutOutput{}, nil
+}
+
+// RevokeToken implements client.TokenRevoker. The refresh token is revoked, which ends the session it belongs to.
+func (up *UserPool) RevokeToken(ctx aws.Context, clientID, token string) error {
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+
+	if err := up.checkClientID(&clientID); err != nil {
+		return err
+	}
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+	delete(up.refreshTokens, token)
+
+	return nil
+}
+
+func (up *UserPool) checkClientID(clientID *string) error {
+	if aws.StringValue(clientID) != up.clientID {
+		return awserr.New(cip.ErrCodeResourceNotFoundException, fmt.Sprintf("User pool client %s does not exist.", aws.StringValue(clientID)), nil)
+	}
+
+	return nil
+}
+
+// authorize returns the user the access token was issued to. Must be called with mu held.
+func (up *UserPool) authorize(token *string) (*User, error) {
+	at, exists := up.accessTokens[aws.StringValue(token)]
+	if !exists {
+		return nil, errNotAuthorized("Invalid Access Token")
+	}
+
+	if time.Now().After(at.expires) {
+		return nil, errNotAuthorized("Access Token has expired")
+	}
+
+	user, exists := up.users[at.username]
+	if !exists {
+		return nil, awserr.New(cip.ErrCodeUserNotFoundException, "User does not exist.", nil)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,350 @@
+package cognitotest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+	"github.com/larwef/cognito/client"
+	"github.com/larwef/cognito/verifier"
+)
+
+func newTestUserPool(t *testing.T, users ...User) *UserPool {
+	pool, err := NewUserPool("", "")
+	if err != nil {
+		t.Fatalf("Error creating user pool: %v", err)
+	}
+
+	for _, user := range users {
+		if err := pool.AddUser(user); err != nil {
+			t.Fatalf("Error adding user: %v", err)
+		}
+	}
+
+	return pool
+}
+
+func newTestTokenSource(t *testing.T, pool *UserPool, username, password string) *client.TokenSource {
+	ts, err := client.NewTokenSource(&client.Config{
+		UserpoolID:       pool.ID(),
+		ClientID:         pool.ClientID(),
+		Username:         username,
+		Password:         password,
+		IdentityProvider: pool,
+	})
+	if err != nil {
+		t.Fatalf("Error getting TokenSource: %v", err)
+	}
+
+	return ts
+}
+
+func TestUserPool_TokenSource(t *testing.T) {
+	pool := newTestUserPool(t, User{
+		Username:   "testUser",
+		Password:   "Password123!",
+		Attributes: map[string]string{"email": "test@example.com"},
+	})
+	ts := newTestTokenSource(t, pool, "testUser", "Password123!")
+
+	token, err := ts.GetToken()
+	if err != nil {
+		t.Fatalf("GetToken returned an error: %v", err)
+	}
+
+	if token.RefreshToken == "" {
+		t.Error("Expected a refresh token")
+	}
+
+	jv := &verifier.JWTVerifier{Issuer: pool.Issuer(), Client: pool.HTTPClient()}
+	idToken, err := jv.Parse(token.IDToken)
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+
+	if idToken.Claims["cognito:username"] != "testUser" || idToken.Claims["email"] != "test@example.com" {
+		t.Errorf("Unexpected ID token claims: %v", idToken.Claims)
+	}
+
+	if _, err := jv.Parse(token.AccessToken); err != nil {
+		t.Errorf("Parse returned an error for the access token: %v", err)
+	}
+
+	user, err := ts.GetUser(context.Background())
+	if err != nil {
+		t.Fatalf("GetUser returned an error: %v", err)
+	}
+
+	if user.Username != "testUser" || user.Attributes["email"] != "test@example.com" {
+		t.Errorf("Unexpected user: %+v", user)
+	}
+}
+
+func TestUserPool_WrongPassword(t *testing.T) {
+	pool := newTestUserPool(t, User{Username: "testUser", Password: "Password123!"})
+	ts := newTestTokenSource(t, pool, "testUser", "WrongPassword")
+
+	if _, err := ts.GetToken(); err == nil {
+		t.Error("Expected GetToken to return an error")
+	}
+}
+
+func TestUserPool_UnknownUser(t *testing.T) {
+	pool := newTestUserPool(t)
+
+	_, err := pool.InitiateAuth(&cip.InitiateAuthInput{
+		AuthFlow:       aws.String(cip.AuthFlowTypeUserSrpAuth),
+		AuthParameters: map[string]*string{"USERNAME": aws.String("unknown"), "SRP_A": aws.String("abc")},
+		ClientId:       aws.String(pool.ClientID()),
+	})
+
+	awsErr, ok := err.(awserr.Error)
+	if !ok || awsErr.Code() != cip.ErrCodeUserNotFoundException {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestUserPool_ForceChangePassword(t *testing.T) {
+	pool := newTestUserPool(t, User{
+		Username: "testUser",
+		Password: "Password123!",
+		Status:   cip.UserStatusTypeForceChangePassword,
+	})
+	ts := newTestTokenSource(t, pool, "testUser", "Password123!")
+
+	if _, err := ts.GetToken(); err != nil {
+		t.Fatalf("GetToken returned an error: %v", err)
+	}
+
+	user, _ := pool.User("testUser")
+	if user.Status != cip.UserStatusTypeConfirmed {
+		t.Errorf("Unexpected user status: %s", user.Status)
+	}
+
+	if user.Password != "Password123!" {
+		t.Errorf("Password was not changed back. Got: %s", user.Password)
+	}
+
+	if _, err := newTestTokenSource(t, pool, "testUser", "Password123!").GetToken(); err != nil {
+		t.Errorf("GetToken returned an error after changing password: %v", err)
+	}
+}
+
+func TestUserPool_MFA(t *testing.T) {
+	pool := newTestUserPool(t, User{Username: "testUser", Password: "Password123!", MFACode: "123456"})
+	ts := newTestTokenSource(t, pool, "testUser", "Password123!")
+
+	if _, err := ts.GetToken(); err == nil {
+		t.Fatal("Expected GetToken to return an error for unsupported MFA challenge")
+	}
+
+	res, err := pool.RespondToAuthChallenge(&cip.RespondToAuthChallengeInput{
+		ChallengeName:      aws.String(cip.ChallengeNameTypeSoftwareTokenMfa),
+		ChallengeResponses: map[string]*string{"SOFTWARE_TOKEN_MFA_CODE": aws.String("123456")},
+		ClientId:           aws.String(pool.ClientID()),
+		Session:            aws.String("unknown"),
+	})
+	if err == nil {
+		t.Errorf("Expected an error for an unknown session. Got: %v", res)
+	}
+}
+
+func TestUserPool_RefreshAndSignOut(t *testing.T) {
+	pool := newTestUserPool(t, User{Username: "testUser", Password: "Password123!"})
+	ts := newTestTokenSource(t, pool, "testUser", "Password123!")
+
+	token, err := ts.GetToken()
+	if err != nil {
+		t.Fatalf("GetToken returned an error: %v", err)
+	}
+	refreshToken := token.RefreshToken
+
+	res, err := pool.InitiateAuth(&cip.InitiateAuthInput{
+		AuthFlow:       aws.String(cip.AuthFlowTypeRefreshTokenAuth),
+		AuthParameters: map[string]*string{"REFRESH_TOKEN": aws.String(refreshToken)},
+		ClientId:       aws.String(pool.ClientID()),
+	})
+	if err != nil {
+		t.Fatalf("InitiateAuth returned an error: %v", err)
+	}
+
+	if res.AuthenticationResult.RefreshToken != nil {
+		t.Error("Refresh should not return a new refresh token")
+	}
+
+	if err := ts.SignOut(context.Background(), true); err != nil {
+		t.Fatalf("SignOut returned an error: %v", err)
+	}
+
+	_, err = pool.InitiateAuth(&cip.InitiateAuthInput{
+		AuthFlow:       aws.String(cip.AuthFlowTypeRefreshTokenAuth),
+		AuthParameters: map[string]*string{"REFRESH_TOKEN": aws.String(refreshToken)},
+		ClientId:       aws.String(pool.ClientID()),
+	})
+
+	awsErr, ok := err.(awserr.Error)
+	if !ok || awsErr.Code() != cip.ErrCodeNotAuthorizedException {
+		t.Errorf("Unexpected error after SignOut: %v", err)
+	}
+}
+
+func TestUserPool_RevokeToken(t *testing.T) {
+	pool := newTestUserPool(t, User{Username: "testUser", Password: "Password123!"})
+	ts := newTestTokenSource(t, pool, "testUser", "Password123!")
+
+	token, err := ts.GetToken()
+	if err != nil {
+		t.Fatalf("GetToken returned an error: %v", err)
+	}
+
+	if err := ts.SignOut(context.Background(), false); err != nil {
+		t.Fatalf("SignOut returned an error: %v", err)
+	}
+
+	_, err = pool.InitiateAuth(&cip.InitiateAuthInput{
+		AuthFlow:       aws.String(cip.AuthFlowTypeRefreshTokenAuth),
+		AuthParameters: map[string]*string{"REFRESH_TOKEN": aws.String(token.RefreshToken)},
+		ClientId:       aws.String(pool.ClientID()),
+	})
+
+	awsErr, ok := err.(awserr.Error)
+	if !ok || awsErr.Code() != cip.ErrCodeNotAuthorizedException {
+		t.Errorf("Unexpected error refreshing a revoked token: %v", err)
+	}
+
+	if err := pool.RevokeToken(context.Background(), "unknown", token.RefreshToken); err == nil {
+		t.Error("Expected an error revoking a token with an unknown client ID")
+	}
+}
+
+func TestUserPool_UserAttributes(t *testing.T) {
+	pool := newTestUserPool(t, User{
+		Username:         "testUser",
+		Password:         "Password123!",
+		Attributes:       map[string]string{"email": "test@example.com", "nickname": "test"},
+		VerificationCode: "123456",
+	})
+	ts := newTestTokenSource(t, pool, "testUser", "Password123!")
+	ctx := context.Background()
+
+	if err := ts.UpdateUserAttributes(ctx, map[string]string{"email": "new@example.com"}); err != nil {
+		t.Fatalf("UpdateUserAttributes returned an error: %v", err)
+	}
+
+	if err := ts.UpdateUserAttributes(ctx, map[string]string{"sub": "sub"}); err == nil {
+		t.Error("Expected an error updating sub")
+	}
+
+	if err := ts.VerifyUserAttribute(ctx, "email", "654321"); err == nil {
+		t.Error("Expected an error verifying with the wrong code")
+	}
+
+	if err := ts.VerifyUserAttribute(ctx, "email", "123456"); err != nil {
+		t.Fatalf("VerifyUserAttribute returned an error: %v", err)
+	}
+
+	if err := ts.DeleteUserAttributes(ctx, "nickname"); err != nil {
+		t.Fatalf("DeleteUserAttributes returned an error: %v", err)
+	}
+
+	user, err := ts.GetUser(ctx)
+	if err != nil {
+		t.Fatalf("GetUser returned an error: %v", err)
+	}
+
+	expected := map[string]string{"email": "new@example.com", "email_verified": "true"}
+	for name, value := range expected {
+		if user.Attributes[name] != value {
+			t.Errorf("Unexpected value: %v for %s. Expected: %v", user.Attributes[name], name, value)
+		}
+	}
+
+	if _, exists := user.Attributes["nickname"]; exists {
+		t.Error("nickname was not deleted")
+	}
+}
+
+func TestUserPool_SetUserMFAPreference(t *testing.T) {
+	pool := newTestUserPool(t, User{Username: "testUser", Password: "Password123!", MFACode: "123456"})
+	// Turn the challenge off, as the TokenSource does not answer it.
+	pool.users["testUser"].mfaDisabled = true
+	ts := newTestTokenSource(t, pool, "testUser", "Password123!")
+	ctx := context.Background()
+
+	if err := ts.SetUserMFAPreference(ctx, &client.MFAPreference{Enabled: true}, nil); err == nil {
+		t.Error("Expected an error enabling SMS MFA")
+	}
+
+	if err := ts.SetUserMFAPreference(ctx, nil, &client.MFAPreference{Enabled: true, Preferred: true}); err != nil {
+		t.Fatalf("SetUserMFAPreference returned an error: %v", err)
+	}
+
+	user, err := ts.GetUser(ctx)
+	if err != nil {
+		t.Fatalf("GetUser returned an error: %v", err)
+	}
+
+	if user.PreferredMFASetting != cip.ChallengeNameTypeSoftwareTokenMfa {
+		t.Errorf("Unexpected value: %v for PreferredMFASetting. Expected: %v", user.PreferredMFASetting, cip.ChallengeNameTypeSoftwareTokenMfa)
+	}
+
+	other := newTestUserPool(t, User{Username: "testUser", Password: "Password123!"})
+	ts = newTestTokenSource(t, other, "testUser", "Password123!")
+	if err := ts.SetUserMFAPreference(ctx, nil, &client.MFAPreference{Enabled: true}); err == nil {
+		t.Error("Expected an error enabling software token MFA for a user without MFACode")
+	}
+}
+
+func TestUserPool_Devices(t *testing.T) {
+	user := User{Username: "testUser", Password: "Password123!"}
+	for i := 0; i < 61; i++ {
+		user.Devices = append(user.Devices, Device{
+			Key:        fmt.Sprintf("eu-west-1_device%d", i),
+			Attributes: map[string]string{"device_name": "laptop"},
+		})
+	}
+	pool := newTestUserPool(t, user)
+	ts := newTestTokenSource(t, pool, "testUser", "Password123!")
+	ctx := context.Background()
+
+	devices, err := ts.ListDevices(ctx)
+	if err != nil {
+		t.Fatalf("ListDevices returned an error: %v", err)
+	}
+
+	if len(devices) != 61 {
+		t.Fatalf("Unexpected number of devices: %d. Expected: %d", len(devices), 61)
+	}
+
+	if err := ts.UpdateDeviceStatus(ctx, "eu-west-1_device0", true); err != nil {
+		t.Fatalf("UpdateDeviceStatus returned an error: %v", err)
+	}
+
+	device, err := ts.GetDevice(ctx, "eu-west-1_device0")
+	if err != nil {
+		t.Fatalf("GetDevice returned an error: %v", err)
+	}
+
+	if device.Attributes["device_status"] != cip.DeviceRememberedStatusTypeRemembered || device.Attributes["device_name"] != "laptop" {
+		t.Errorf("Unexpected device attributes: %v", device.Attributes)
+	}
+
+	if device.CreateDate.IsZero() {
+		t.Error("CreateDate was not set")
+	}
+
+	if err := ts.ForgetDevice(ctx, "eu-west-1_device0"); err != nil {
+		t.Fatalf("ForgetDevice returned an error: %v", err)
+	}
+
+	if _, err := ts.GetDevice(ctx, "eu-west-1_device0"); err == nil {
+		t.Error("Expected an error getting a forgotten device")
+	}
+
+	if u, _ := pool.User("testUser"); len(u.Devices) != 60 {
+		t.Errorf("Unexpected number of devices: %d. Expected: %d", len(u.Devices), 60)
+	}
+}
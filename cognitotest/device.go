@@ -0,0 +1,189 @@
+package cognitotest
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+)
+
+// Maximum number of devices returned by ListDevices, like Cognito.
+const listDevicesLimit = 60
+
+// Device is a device remembered for a user.
+type Device struct {
+	Key        string
+	Attributes map[string]string
+
+	createDate       time.Time
+	lastModifiedDate time.Time
+}
+
+func (d *Device) deviceType() *cip.DeviceType {
+	deviceType := &cip.DeviceType{
+		DeviceKey:              aws.String(d.Key),
+		DeviceCreateDate:       aws.Time(d.createDate),
+		DeviceLastModifiedDate: aws.Time(d.lastModifiedDate),
+	}
+	for name, value := range d.Attributes {
+		deviceType.DeviceAttributes = append(deviceType.DeviceAttributes, &cip.AttributeType{Name: aws.String(name), Value: aws.String(value)})
+	}
+
+	return deviceType
+}
+
+func errDeviceNotFound() error {
+	return awserr.New(cip.ErrCodeResourceNotFoundException, "Device does not exist.", nil)
+}
+
+// device returns the index of the device with the given key. Must be called with mu held.
+func (user *User) device(deviceKey *string) (int, error) {
+	for i := range user.Devices {
+		if user.Devices[i].Key == aws.StringValue(deviceKey) {
+			return i, nil
+		}
+	}
+
+	return 0, errDeviceNotFound()
+}
+
+// ListDevices implements CognitoIdentityProviderAPI.
+func (up *UserPool) ListDevices(input *cip.ListDevicesInput) (*cip.ListDevicesOutput, error) {
+	return up.ListDevicesWithContext(aws.BackgroundContext(), input)
+}
+
+// ListDevicesWithContext implements CognitoIdentityProviderAPI. The devices are paginated like by Cognito.
+func (up *UserPool) ListDevicesWithContext(ctx aws.Context, input *cip.ListDevicesInput, _ ...request.Option) (*cip.ListDevicesOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	user, err := up.authorize(input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int(aws.Int64Value(input.Limit))
+	if limit <= 0 || limit > listDevicesLimit {
+		limit = listDevicesLimit
+	}
+
+	start := 0
+	if input.PaginationToken != nil {
+		start, err = strconv.Atoi(aws.StringValue(input.PaginationToken))
+		if err != nil || start < 0 || start > len(user.Devices) {
+			return nil, errInvalidParameter("Invalid pagination token")
+		}
+	}
+
+	output := &cip.ListDevicesOutput{Devices: []*cip.DeviceType{}}
+	end := start + limit
+	if end < len(user.Devices) {
+		output.PaginationToken = aws.String(strconv.Itoa(end))
+	} else {
+		end = len(user.Devices)
+	}
+	for i := start; i < end; i++ {
+		output.Devices = append(output.Devices, user.Devices[i].deviceType())
+	}
+
+	return output, nil
+}
+
+// GetDevice implements CognitoIdentityProviderAPI.
+func (up *UserPool) GetDevice(input *cip.GetDeviceInput) (*cip.GetDeviceOutput, error) {
+	return up.GetDeviceWithContext(aws.BackgroundContext(), input)
+}
+
+// GetDeviceWithContext implements CognitoIdentityProviderAPI.
+func (up *UserPool) GetDeviceWithContext(ctx aws.Context, input *cip.GetDeviceInput, _ ...request.Option) (*cip.GetDeviceOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	user, err := up.authorize(input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := user.device(input.DeviceKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cip.GetDeviceOutput{Device: user.Devices[i].deviceType()}, nil
+}
+
+// UpdateDeviceStatus implements CognitoIdentityProviderAPI.
+func (up *UserPool) UpdateDeviceStatus(input *cip.UpdateDeviceStatusInput) (*cip.UpdateDeviceStatusOutput, error) {
+	return up.UpdateDeviceStatusWithContext(aws.BackgroundContext(), input)
+}
+
+// UpdateDeviceStatusWithContext implements CognitoIdentityProviderAPI. The status is kept in the device_status
+// attribute of the device.
+func (up *UserPool) UpdateDeviceStatusWithContext(ctx aws.Context, input *cip.UpdateDeviceStatusInput, _ ...request.Option) (*cip.UpdateDeviceStatusOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	status := aws.StringValue(input.DeviceRememberedStatus)
+	if status != cip.DeviceRememberedStatusTypeRemembered && status != cip.DeviceRememberedStatusTypeNotRemembered {
+		return nil, errInvalidParameter("Invalid device remembered status: %s", status)
+	}
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	user, err := up.authorize(input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := user.device(input.DeviceKey)
+	if err != nil {
+		return nil, err
+	}
+	user.Devices[i].Attributes["device_status"] = status
+	user.Devices[i].lastModifiedDate = time.Now()
+
+	return &cip.UpdateDeviceStatusOutput{}, nil
+}
+
+// ForgetDevice implements CognitoIdentityProviderAPI.
+func (up *UserPool) ForgetDevice(input *cip.ForgetDeviceInput) (*cip.ForgetDeviceOutput, error) {
+	return up.ForgetDeviceWithContext(aws.BackgroundContext(), input)
+}
+
+// ForgetDeviceWithContext implements CognitoIdentityProviderAPI.
+func (up *UserPool) ForgetDeviceWithContext(ctx aws.Context, input *cip.ForgetDeviceInput, _ ...request.Option) (*cip.ForgetDeviceOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	user, err := up.authorize(input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := user.device(input.DeviceKey)
+	if err != nil {
+		return nil, err
+	}
+	// Copies returned by User share the slice, so a new one is made.
+	devices := make([]Device, 0, len(user.Devices)-1)
+	user.Devices = append(append(devices, user.Devices[:i]...), user.Devices[i+1:]...)
+
+	return &cip.ForgetDeviceOutput{}, nil
+}
@@ -0,0 +1,170 @@
+package cognitotest
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+)
+
+type jwk struct {
+	Alg string `json:"alg"`
+	E   string `json:"e"`
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	Use string `json:"use"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// JWKS returns the JSON Web Key Set with the public key used to sign the tokens issued by the user pool.
+func (up *UserPool) JWKS() []byte {
+	b, _ := json.Marshal(jwks{
+		Keys: []jwk{{
+			Alg: "RS256",
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(up.key.E)).Bytes()),
+			Kid: up.kid,
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(up.key.N.Bytes()),
+			Use: "sig",
+		}},
+	})
+
+	return b
+}
+
+// HTTPClient returns a http.Client serving the JWKS of the user pool at Issuer()+"/.well-known/jwks.json" without
+// network access. Requests to other URLs get a 404 response.
+func (up *UserPool) HTTPClient() *http.Client {
+	return &http.Client{Transport: jwksTransport{up}}
+}
+
+type jwksTransport struct {
+	up *UserPool
+}
+
+func (t jwksTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res := &http.Response{
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Request:    req,
+	}
+
+	if req.Method == http.MethodGet && req.URL.String() == t.up.Issuer()+"/.well-known/jwks.json" {
+		body := t.up.JWKS()
+		res.StatusCode = http.StatusOK
+		res.Status = "200 OK"
+		res.Header.Set("Content-Type", "application/json")
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+		res.ContentLength = int64(len(body))
+		return res, nil
+	}
+
+	res.StatusCode = http.StatusNotFound
+	res.Status = "404 Not Found"
+	res.Body = ioutil.NopCloser(bytes.NewReader(nil))
+	return res, nil
+}
+
+// issueTokens returns a new authentication result for the user. Must be called with mu held.
+func (up *UserPool) issueTokens(user *User, withRefreshToken bool) (*cip.AuthenticationResultType, error) {
+	now := time.Now()
+	exp := now.Add(up.TokenValidity)
+
+	jti, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	accessTkn, err := up.sign(map[string]interface{}{
+		"sub":       user.sub,
+		"token_use": "access",
+		"scope":     "aws.cognito.signin.user.admin",
+		"auth_time": now.Unix(),
+		"iss":       up.issuer,
+		"exp":       exp.Unix(),
+		"iat":       now.Unix(),
+		"jti":       jti,
+		"client_id": up.clientID,
+		"username":  user.Username,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	idClaims := map[string]interface{}{
+		"sub":              user.sub,
+		"aud":              up.clientID,
+		"token_use":        "id",
+		"auth_time":        now.Unix(),
+		"iss":              up.issuer,
+		"cognito:username": user.Username,
+		"exp":              exp.Unix(),
+		"iat":              now.Unix(),
+	}
+	for name, value := range user.Attributes {
+		if _, exists := idClaims[name]; !exists {
+			idClaims[name] = value
+		}
+	}
+	idToken, err := up.sign(idClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	up.accessTokens[accessTkn] = &accessToken{username: user.Username, expires: exp}
+
+	result := &cip.AuthenticationResultType{
+		AccessToken: aws.String(accessTkn),
+		ExpiresIn:   aws.Int64(int64(up.TokenValidity / time.Second)),
+		IdToken:     aws.String(idToken),
+		TokenType:   aws.String("Bearer"),
+	}
+
+	if withRefreshToken {
+		refreshToken, err := randomString(64)
+		if err != nil {
+			return nil, err
+		}
+		up.refreshTokens[refreshToken] = user.Username
+		result.RefreshToken = aws.String(refreshToken)
+	}
+
+	return result, nil
+}
+
+func (up *UserPool) sign(claims map[string]interface{}) (string, error) {
+	header, err := json.Marshal(map[string]string{"kid": up.kid, "alg": "RS256"})
+	if err != nil {
+		return "", fmt.Errorf("error marshalling header: %v", err)
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling claims: %v", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(nil, up.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("error signing token: %v", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
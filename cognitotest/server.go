@@ -0,0 +1,277 @@
+package cognitotest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/private/protocol/json/jsonutil"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+)
+
+const (
+	targetPrefix = "AWSCognitoIdentityProviderService."
+	contentType  = "application/x-amz-json-1.1"
+)
+
+// Server is a local Cognito emulator serving a UserPool over HTTP. It implements the Cognito Identity Provider JSON
+// API at the root path, the JWKS and OpenID configuration under the issuer URL, and the OAuth2 token endpoint at
+// /oauth2/token.
+//
+// The issuer of the tokens is URL+"/"+poolID, so a verifier.JWTVerifier with Issuer set to Issuer() fetches the keys
+// from the Server.
+type Server struct {
+	*httptest.Server
+	pool *UserPool
+}
+
+// NewServer starts and returns a new Server for the pool. The issuer of the pool is changed to the URL of the Server.
+// The caller should call Close when finished, to shut it down.
+func NewServer(pool *UserPool) *Server {
+	s := NewUnstartedServer(pool)
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer returns a new Server for the pool but doesn't start it. The caller should call Start or StartTLS
+// before use.
+func NewUnstartedServer(pool *UserPool) *Server {
+	s := &Server{pool: pool}
+	s.Server = httptest.NewUnstartedServer(s)
+	return s
+}
+
+// Start starts the server and sets the issuer of the pool to the URL of the server.
+func (s *Server) Start() {
+	s.Server.Start()
+	s.pool.setIssuer(s.URL + "/" + s.pool.id)
+}
+
+// StartTLS starts TLS on the server and sets the issuer of the pool to the URL of the server.
+func (s *Server) StartTLS() {
+	s.Server.StartTLS()
+	s.pool.setIssuer(s.URL + "/" + s.pool.id)
+}
+
+// Issuer returns the issuer of the tokens issued by the Server.
+func (s *Server) Issuer() string {
+	return s.pool.Issuer()
+}
+
+// UserPool returns the pool served by the Server.
+func (s *Server) UserPool() *UserPool {
+	return s.pool
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	issuerPath := "/" + s.pool.id
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/" && strings.HasPrefix(r.Header.Get("X-Amz-Target"), targetPrefix):
+		s.serveAPI(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == issuerPath+"/.well-known/jwks.json":
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(s.pool.JWKS())
+	case r.Method == http.MethodGet && r.URL.Path == issuerPath+"/.well-known/openid-configuration":
+		s.serveOpenIDConfiguration(w)
+	case r.URL.Path == "/oauth2/token":
+		s.serveToken(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	operation := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), targetPrefix)
+	ctx := r.Context()
+
+	var output interface{}
+	var err error
+	switch operation {
+	case "InitiateAuth":
+		input := &cip.InitiateAuthInput{}
+		if err = unmarshalInput(r, input); err == nil {
+			output, err = s.pool.InitiateAuthWithContext(ctx, input)
+		}
+	case "RespondToAuthChallenge":
+		input := &cip.RespondToAuthChallengeInput{}
+		if err = unmarshalInput(r, input); err == nil {
+			output, err = s.pool.RespondToAuthChallengeWithContext(ctx, input)
+		}
+	case "ChangePassword":
+		input := &cip.ChangePasswordInput{}
+		if err = unmarshalInput(r, input); err == nil {
+			output, err = s.pool.ChangePasswordWithContext(ctx, input)
+		}
+	case "GetUser":
+		input := &cip.GetUserInput{}
+		if err = unmarshalInput(r, input); err == nil {
+			output, err = s.pool.GetUserWithContext(ctx, input)
+		}
+	case "GlobalSignOut":
+		input := &cip.GlobalSignOutInput{}
+		if err = unmarshalInput(r, input); err == nil {
+			output, err = s.pool.GlobalSignOutWithContext(ctx, input)
+		}
+	case "UpdateUserAttributes":
+		input := &cip.UpdateUserAttributesInput{}
+		if err = unmarshalInput(r, input); err == nil {
+			output, err = s.pool.UpdateUserAttributesWithContext(ctx, input)
+		}
+	case "VerifyUserAttribute":
+		input := &cip.VerifyUserAttributeInput{}
+		if err = unmarshalInput(r, input); err == nil {
+			output, err = s.pool.VerifyUserAttributeWithContext(ctx, input)
+		}
+	case "DeleteUserAttributes":
+		input := &cip.DeleteUserAttributesInput{}
+		if err = unmarshalInput(r, input); err == nil {
+			output, err = s.pool.DeleteUserAttributesWithContext(ctx, input)
+		}
+	case "SetUserMFAPreference":
+		input := &cip.SetUserMFAPreferenceInput{}
+		if err = unmarshalInput(r, input); err == nil {
+			output, err = s.pool.SetUserMFAPreferenceWithContext(ctx, input)
+		}
+	case "ListDevices":
+		input := &cip.ListDevicesInput{}
+		if err = unmarshalInput(r, input); err == nil {
+			output, err = s.pool.ListDevicesWithContext(ctx, input)
+		}
+	case "GetDevice":
+		input := &cip.GetDeviceInput{}
+		if err = unmarshalInput(r, input); err == nil {
+			output, err = s.pool.GetDeviceWithContext(ctx, input)
+		}
+	case "UpdateDeviceStatus":
+		input := &cip.UpdateDeviceStatusInput{}
+		if err = unmarshalInput(r, input); err == nil {
+			output, err = s.pool.UpdateDeviceStatusWithContext(ctx, input)
+		}
+	case "ForgetDevice":
+		input := &cip.ForgetDeviceInput{}
+		if err = unmarshalInput(r, input); err == nil {
+			output, err = s.pool.ForgetDeviceWithContext(ctx, input)
+		}
+	case "RevokeToken":
+		input := &revokeTokenInput{}
+		if err = unmarshalInput(r, input); err == nil {
+			output, err = struct{}{}, s.pool.RevokeToken(ctx, aws.StringValue(input.ClientId), aws.StringValue(input.Token))
+		}
+	default:
+		err = awserr.New("UnknownOperationException", "Unknown operation "+operation, nil)
+	}
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	body, err := jsonutil.BuildJSON(output)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// revokeTokenInput is the input of RevokeToken, which is missing from the version of aws-sdk-go in use.
+type revokeTokenInput struct {
+	_ struct{} `type:"structure"`
+
+	ClientId *string `type:"string"`
+	Token    *string `type:"string"`
+}
+
+func unmarshalInput(r *http.Request, input interface{}) error {
+	if err := jsonutil.UnmarshalJSON(input, r.Body); err != nil {
+		return awserr.New("SerializationException", "Unable to parse request body", err)
+	}
+
+	return nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code, message, status := "InternalErrorException", err.Error(), http.StatusInternalServerError
+	if awsErr, ok := err.(awserr.Error); ok {
+		code, message, status = awsErr.Code(), awsErr.Message(), http.StatusBadRequest
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Amzn-ErrorType", code)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"__type": code, "message": message})
+}
+
+func (s *Server) serveOpenIDConfiguration(w http.ResponseWriter) {
+	issuer := s.pool.Issuer()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                                issuer,
+		"jwks_uri":                              issuer + "/.well-known/jwks.json",
+		"token_endpoint":                        s.URL + "/oauth2/token",
+		"grant_types_supported":                 []string{"refresh_token"},
+		"response_types_supported":              []string{"token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"token_endpoint_auth_methods_supported": []string{"none"},
+	})
+}
+
+// serveToken implements the refresh_token grant of the OAuth2 token endpoint.
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeOAuth2Error(w, "invalid_request")
+		return
+	}
+
+	if grantType := r.PostForm.Get("grant_type"); grantType != "refresh_token" {
+		writeOAuth2Error(w, "unsupported_grant_type")
+		return
+	}
+
+	clientID := r.PostForm.Get("client_id")
+	if username, _, ok := r.BasicAuth(); ok {
+		clientID = username
+	}
+
+	res, err := s.pool.InitiateAuthWithContext(r.Context(), &cip.InitiateAuthInput{
+		AuthFlow:       aws.String(cip.AuthFlowTypeRefreshTokenAuth),
+		AuthParameters: map[string]*string{"REFRESH_TOKEN": aws.String(r.PostForm.Get("refresh_token"))},
+		ClientId:       aws.String(clientID),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == cip.ErrCodeResourceNotFoundException {
+			writeOAuth2Error(w, "invalid_client")
+			return
+		}
+		writeOAuth2Error(w, "invalid_grant")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": aws.StringValue(res.AuthenticationResult.AccessToken),
+		"id_token":     aws.StringValue(res.AuthenticationResult.IdToken),
+		"token_type":   aws.StringValue(res.AuthenticationResult.TokenType),
+		"expires_in":   aws.Int64Value(res.AuthenticationResult.ExpiresIn),
+	})
+}
+
+func writeOAuth2Error(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
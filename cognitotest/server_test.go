@@ -0,0 +1,144 @@
+package cognitotest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/larwef/cognito/client"
+	"github.com/larwef/cognito/verifier"
+)
+
+func TestServer_TokenSource(t *testing.T) {
+	pool := newTestUserPool(t, User{
+		Username: "testUser",
+		Password: "Password123!",
+		Status:   "FORCE_CHANGE_PASSWORD",
+		Devices:  []Device{{Key: "eu-west-1_device", Attributes: map[string]string{"device_name": "laptop"}}},
+	})
+	server := NewServer(pool)
+	defer server.Close()
+
+	configs := map[string]*client.Config{
+		"SDK": {
+			AWSConfig: &aws.Config{
+				Region:      aws.String("eu-west-1"),
+				Endpoint:    aws.String(server.URL),
+				Credentials: credentials.AnonymousCredentials,
+			},
+		},
+		"Lightweight": {
+			Lightweight: true,
+			Endpoint:    server.URL,
+		},
+	}
+
+	for name, conf := range configs {
+		conf.UserpoolID = pool.ID()
+		conf.ClientID = pool.ClientID()
+		conf.Username = "testUser"
+		conf.Password = "Password123!"
+
+		ts, err := client.NewTokenSource(conf)
+		if err != nil {
+			t.Fatalf("%s: Error getting TokenSource: %v", name, err)
+		}
+
+		token, err := ts.GetToken()
+		if err != nil {
+			t.Fatalf("%s: GetToken returned an error: %v", name, err)
+		}
+
+		jv := &verifier.JWTVerifier{Issuer: server.Issuer()}
+		if _, err := jv.Parse(token.IDToken); err != nil {
+			t.Errorf("%s: Parse returned an error: %v", name, err)
+		}
+
+		if err := ts.UpdateUserAttributes(context.Background(), map[string]string{"nickname": name}); err != nil {
+			t.Errorf("%s: UpdateUserAttributes returned an error: %v", name, err)
+		}
+
+		devices, err := ts.ListDevices(context.Background())
+		if err != nil {
+			t.Errorf("%s: ListDevices returned an error: %v", name, err)
+		} else if len(devices) != 1 || devices[0].Attributes["device_name"] != "laptop" {
+			t.Errorf("%s: Unexpected devices: %v", name, devices)
+		}
+
+		if err := ts.SignOut(context.Background(), false); err != nil {
+			t.Errorf("%s: SignOut returned an error: %v", name, err)
+		}
+	}
+}
+
+func TestServer_OpenIDConfiguration(t *testing.T) {
+	server := NewServer(newTestUserPool(t))
+	defer server.Close()
+
+	res, err := http.Get(server.Issuer() + "/.well-known/openid-configuration")
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	defer res.Body.Close()
+
+	var conf map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&conf); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+
+	if conf["issuer"] != server.Issuer() || conf["jwks_uri"] != server.Issuer()+"/.well-known/jwks.json" {
+		t.Errorf("Unexpected configuration: %v", conf)
+	}
+}
+
+func TestServer_OAuth2Token(t *testing.T) {
+	pool := newTestUserPool(t, User{Username: "testUser", Password: "Password123!"})
+	server := NewServer(pool)
+	defer server.Close()
+
+	token, err := newTestTokenSource(t, pool, "testUser", "Password123!").GetToken()
+	if err != nil {
+		t.Fatalf("GetToken returned an error: %v", err)
+	}
+
+	res, err := http.PostForm(server.URL+"/oauth2/token", url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {pool.ClientID()},
+		"refresh_token": {token.RefreshToken},
+	})
+	if err != nil {
+		t.Fatalf("PostForm returned an error: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("Unexpected status code: %d", res.StatusCode)
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("Error decoding response: %v", err)
+	}
+
+	if body["access_token"] == "" || body["token_type"] != "Bearer" {
+		t.Errorf("Unexpected response: %v", body)
+	}
+
+	res, err = http.PostForm(server.URL+"/oauth2/token", url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {pool.ClientID()},
+		"refresh_token": {"invalid"},
+	})
+	if err != nil {
+		t.Fatalf("PostForm returned an error: %v", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Unexpected status code for invalid refresh token: %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,110 @@
+package cognitotest
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/larwef/cognito/srp"
+)
+
+const saltSize = 16
+
+// ErrInvalidClaim is returned by VerifyClaim when the claim signature doesn't match.
+var ErrInvalidClaim = errors.New("invalid password claim signature")
+
+// SRPServer is the server side of the SRP-6a variant used by Cognito for the PASSWORD_VERIFIER challenge. It holds the
+// state between InitiateAuth, where B is returned to the client, and RespondToAuthChallenge, where the claim signature
+// is validated.
+type SRPServer struct {
+	userpoolName string
+	username     string
+	salt         *big.Int
+	xB           *big.Int
+	key          []byte
+}
+
+// NewSRPSalt returns a random salt for use with SRPVerifier.
+func NewSRPSalt() (*big.Int, error) {
+	b := make([]byte, saltSize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("error generating salt: %v", err)
+	}
+
+	return big.NewInt(0).SetBytes(b), nil
+}
+
+// SRPVerifier returns the password verifier v = g^x where x = H(salt | H(userpoolName | username | ":" | password)).
+// userpoolName is the part of the user pool ID after the underscore.
+func SRPVerifier(userpoolName, username, password string, salt *big.Int) *big.Int {
+	// Verifier only fails for invalid params, and srp.Cognito is valid.
+	v, _ := srp.Verifier(srp.Cognito, userpoolName+username, password, srp.CognitoSalt(salt))
+	return v
+}
+
+// NewSRPServer computes B = kv + g^b for a client sending A and derives the session key. The verifier v and salt are
+// the ones returned by SRPVerifier and NewSRPSalt. If privateKey (b) is nil a random one is generated, a fixed value
+// can be given to reproduce test vectors.
+func NewSRPServer(userpoolName, username string, salt, v, xA, privateKey *big.Int) (*SRPServer, error) {
+	var server *srp.Server
+	var err error
+	if privateKey == nil {
+		server, err = srp.NewServer(srp.Cognito, v, rand.Reader)
+	} else {
+		server, err = srp.NewServerWithKey(srp.Cognito, v, privateKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := server.ComputeSecret(xA)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SRPServer{
+		userpoolName: userpoolName,
+		username:     username,
+		salt:         salt,
+		xB:           server.B(),
+		key:          srp.CognitoKey(session),
+	}, nil
+}
+
+// Salt returns the salt sent to the client as SALT.
+func (s *SRPServer) Salt() *big.Int {
+	return s.salt
+}
+
+// B returns the public value sent to the client as SRP_B.
+func (s *SRPServer) B() *big.Int {
+	return s.xB
+}
+
+// Key returns the derived key ("Caldera Derived Key") shared with the client.
+func (s *SRPServer) Key() []byte {
+	return s.key
+}
+
+// Signature returns the claim signature the client is expected to send as PASSWORD_CLAIM_SIGNATURE for the secret
+// block and timestamp.
+func (s *SRPServer) Signature(secretBlock []byte, timestamp string) []byte {
+	return srp.CognitoClaimSignature(s.key, s.userpoolName, s.username, secretBlock, timestamp)
+}
+
+// VerifyClaim validates the base64 encoded claim signature sent by the client.
+func (s *SRPServer) VerifyClaim(secretBlock []byte, timestamp, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("error decoding signature: %v", err)
+	}
+
+	if !hmac.Equal(sig, s.Signature(secretBlock, timestamp)) {
+		return ErrInvalidClaim
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package cognitotest
+
+import (
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	"github.com/larwef/cognito/srp"
+)
+
+func TestNewSRPServer_InvalidA(t *testing.T) {
+	salt := big.NewInt(42)
+	v := SRPVerifier("pool", "user", "password", salt)
+
+	xN := srp.Cognito.Group.N
+	for _, xA := range []*big.Int{nil, big.NewInt(0), xN, big.NewInt(0).Mul(xN, big.NewInt(2))} {
+		if _, err := NewSRPServer("pool", "user", salt, v, xA, nil); err == nil {
+			t.Errorf("Expected NewSRPServer to return an error for A: %v", xA)
+		}
+	}
+}
+
+func TestSRPServer_Deterministic(t *testing.T) {
+	salt := big.NewInt(42)
+	v := SRPVerifier("pool", "user", "password", salt)
+	xA := big.NewInt(0).Exp(srp.Cognito.Group.G, big.NewInt(12345), srp.Cognito.Group.N)
+
+	s1, err := NewSRPServer("pool", "user", salt, v, xA, big.NewInt(67890))
+	if err != nil {
+		t.Fatalf("NewSRPServer returned an error: %v", err)
+	}
+
+	s2, _ := NewSRPServer("pool", "user", salt, v, xA, big.NewInt(67890))
+	if s1.B().Cmp(s2.B()) != 0 || string(s1.Key()) != string(s2.Key()) {
+		t.Error("Expected equal B and key for equal private keys")
+	}
+
+	signature := base64.StdEncoding.EncodeToString(s1.Signature([]byte("secret"), "Tue Apr 16 08:43:29 UTC 2019"))
+	if err := s2.VerifyClaim([]byte("secret"), "Tue Apr 16 08:43:29 UTC 2019", signature); err != nil {
+		t.Errorf("VerifyClaim returned an error: %v", err)
+	}
+
+	if err := s2.VerifyClaim([]byte("secret"), "Tue Apr 16 08:43:30 UTC 2019", signature); err != ErrInvalidClaim {
+		t.Errorf("Expected ErrInvalidClaim for a different timestamp. Got: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package cognitotest
+
+import (
+	"crypto/hmac"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+)
+
+// UpdateUserAttributes implements CognitoIdentityProviderAPI.
+func (up *UserPool) UpdateUserAttributes(input *cip.UpdateUserAttributesInput) (*cip.UpdateUserAttributesOutput, error) {
+	return up.UpdateUserAttributesWithContext(aws.BackgroundContext(), input)
+}
+
+// UpdateUserAttributesWithContext implements CognitoIdentityProviderAPI. No verification codes are sent.
+func (up *UserPool) UpdateUserAttributesWithContext(ctx aws.Context, input *cip.UpdateUserAttributesInput, _ ...request.Option) (*cip.UpdateUserAttributesOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	user, err := up.authorize(input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attribute := range input.UserAttributes {
+		if aws.StringValue(attribute.Name) == "sub" {
+			return nil, errInvalidParameter("Cannot modify an immutable attribute: sub")
+		}
+	}
+	for _, attribute := range input.UserAttributes {
+		user.Attributes[aws.StringValue(attribute.Name)] = aws.StringValue(attribute.Value)
+	}
+
+	return &cip.UpdateUserAttributesOutput{}, nil
+}
+
+// VerifyUserAttribute implements CognitoIdentityProviderAPI.
+func (up *UserPool) VerifyUserAttribute(input *cip.VerifyUserAttributeInput) (*cip.VerifyUserAttributeOutput, error) {
+	return up.VerifyUserAttributeWithContext(aws.BackgroundContext(), input)
+}
+
+// VerifyUserAttributeWithContext implements CognitoIdentityProviderAPI. The code must match the VerificationCode of
+// the user, and the attribute is marked as verified by setting <name>_verified to true.
+func (up *UserPool) VerifyUserAttributeWithContext(ctx aws.Context, input *cip.VerifyUserAttributeInput, _ ...request.Option) (*cip.VerifyUserAttributeOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	user, err := up.authorize(input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	name := aws.StringValue(input.AttributeName)
+	if _, exists := user.Attributes[name]; !exists {
+		return nil, errInvalidParameter("Attribute %s does not exist for the user.", name)
+	}
+
+	code := aws.StringValue(input.Code)
+	if user.VerificationCode == "" || !hmac.Equal([]byte(code), []byte(user.VerificationCode)) {
+		return nil, awserr.New(cip.ErrCodeCodeMismatchException, "Invalid verification code provided, please try again.", nil)
+	}
+	user.Attributes[name+"_verified"] = "true"
+
+	return &cip.VerifyUserAttributeOutput{}, nil
+}
+
+// DeleteUserAttributes implements CognitoIdentityProviderAPI.
+func (up *UserPool) DeleteUserAttributes(input *cip.DeleteUserAttributesInput) (*cip.DeleteUserAttributesOutput, error) {
+	return up.DeleteUserAttributesWithContext(aws.BackgroundContext(), input)
+}
+
+// DeleteUserAttributesWithContext implements CognitoIdentityProviderAPI.
+func (up *UserPool) DeleteUserAttributesWithContext(ctx aws.Context, input *cip.DeleteUserAttributesInput, _ ...request.Option) (*cip.DeleteUserAttributesOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	user, err := up.authorize(input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range input.UserAttributeNames {
+		if aws.StringValue(name) == "sub" {
+			return nil, errInvalidParameter("Cannot delete an immutable attribute: sub")
+		}
+	}
+	for _, name := range input.UserAttributeNames {
+		delete(user.Attributes, aws.StringValue(name))
+	}
+
+	return &cip.DeleteUserAttributesOutput{}, nil
+}
+
+// SetUserMFAPreference implements CognitoIdentityProviderAPI.
+func (up *UserPool) SetUserMFAPreference(input *cip.SetUserMFAPreferenceInput) (*cip.SetUserMFAPreferenceOutput, error) {
+	return up.SetUserMFAPreferenceWithContext(aws.BackgroundContext(), input)
+}
+
+// SetUserMFAPreferenceWithContext implements CognitoIdentityProviderAPI. Only software token MFA is supported, and it
+// can only be enabled for users with an MFACode.
+func (up *UserPool) SetUserMFAPreferenceWithContext(ctx aws.Context, input *cip.SetUserMFAPreferenceInput, _ ...request.Option) (*cip.SetUserMFAPreferenceOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	user, err := up.authorize(input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.SMSMfaSettings != nil && aws.BoolValue(input.SMSMfaSettings.Enabled) {
+		return nil, errInvalidParameter("User does not have delivery config set to turn on SMS_MFA")
+	}
+
+	if settings := input.SoftwareTokenMfaSettings; settings != nil {
+		if aws.BoolValue(settings.Enabled) && user.MFACode == "" {
+			return nil, errInvalidParameter("User has not verified software token mfa")
+		}
+		user.mfaDisabled = !aws.BoolValue(settings.Enabled)
+	}
+
+	return &cip.SetUserMFAPreferenceOutput{}, nil
+}
@@ -0,0 +1,257 @@
+// Package cognitotest provides an in-process fake Cognito user pool for unit tests.
+//
+// A UserPool implements cognitoidentityprovideriface.CognitoIdentityProviderAPI for the operations used by the client
+// package. It verifies the SRP proof sent by the client, keeps track of user status, refresh and access tokens, and
+// issues RS256 signed JWTs. The signing keys are served from Issuer()+"/.well-known/jwks.json" by the http.Client
+// returned by HTTPClient, so tokens can be verified with verifier.JWTVerifier.
+//
+// The operations used by the client package are implemented by the UserPool: authentication, password changes, user
+// attributes, MFA preferences, devices and sign out. Other operations of the interface panic when called. RevokeToken is missing from the version of
+// aws-sdk-go in use, so the UserPool implements client.TokenRevoker for it instead.
+package cognitotest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	cip "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go/service/cognitoidentityprovider/cognitoidentityprovideriface"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultUserpoolID is the user pool ID used by NewUserPool when none is given.
+	DefaultUserpoolID = "eu-west-1_cognitotest"
+
+	// DefaultClientID is the app client ID used by NewUserPool when none is given.
+	DefaultClientID = "cognitotestclient"
+
+	// DefaultTokenValidity is how long issued access and ID tokens are valid.
+	DefaultTokenValidity = time.Hour
+
+	keySize = 2048
+)
+
+// User is a user in the fake user pool.
+type User struct {
+	Username   string
+	Password   string
+	Attributes map[string]string
+
+	// Status is the Cognito user status, eg. cognitoidentityprovider.UserStatusTypeConfirmed or
+	// cognitoidentityprovider.UserStatusTypeForceChangePassword. Defaults to CONFIRMED.
+	Status string
+
+	// MFACode enables the SOFTWARE_TOKEN_MFA challenge for the user when set. The challenge is answered with this code.
+	MFACode string
+
+	// VerificationCode is the code accepted by VerifyUserAttribute. No attribute can be verified when it is empty.
+	VerificationCode string
+
+	// Devices are the devices remembered for the user.
+	Devices []Device
+
+	sub         string
+	salt        *big.Int
+	verifier    *big.Int
+	mfaDisabled bool
+}
+
+// mfaEnabled tells if the SOFTWARE_TOKEN_MFA challenge is enabled for the user.
+func (user *User) mfaEnabled() bool {
+	return user.MFACode != "" && !user.mfaDisabled
+}
+
+// copy returns a copy of the user which shares no attributes or devices with it.
+func (user *User) copy() User {
+	c := *user
+	c.Attributes = copyAttributes(user.Attributes)
+	c.Devices = make([]Device, len(user.Devices))
+	for i, device := range user.Devices {
+		device.Attributes = copyAttributes(device.Attributes)
+		c.Devices[i] = device
+	}
+
+	return c
+}
+
+func copyAttributes(attributes map[string]string) map[string]string {
+	c := make(map[string]string)
+	for name, value := range attributes {
+		c[name] = value
+	}
+
+	return c
+}
+
+// UserPool is a fake Cognito user pool. Use NewUserPool to create one.
+type UserPool struct {
+	cognitoidentityprovideriface.CognitoIdentityProviderAPI
+
+	// TokenValidity is how long issued access and ID tokens are valid.
+	TokenValidity time.Duration
+
+	id       string
+	clientID string
+	kid      string
+	key      *rsa.PrivateKey
+
+	mu            sync.Mutex
+	issuer        string
+	users         map[string]*User
+	srpSessions   map[string]*SRPServer
+	sessions      map[string]*challengeSession
+	refreshTokens map[string]string
+	accessTokens  map[string]*accessToken
+}
+
+type accessToken struct {
+	username string
+	expires  time.Time
+}
+
+// NewUserPool returns a UserPool with the given ID and app client ID. Empty values are replaced by DefaultUserpoolID and
+// DefaultClientID.
+func NewUserPool(userpoolID, clientID string) (*UserPool, error) {
+	if userpoolID == "" {
+		userpoolID = DefaultUserpoolID
+	}
+	if clientID == "" {
+		clientID = DefaultClientID
+	}
+
+	split := strings.SplitN(userpoolID, "_", 2)
+	if len(split) != 2 || split[0] == "" || split[1] == "" {
+		return nil, fmt.Errorf("invalid user pool ID: %s", userpoolID)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("error generating signing key: %v", err)
+	}
+
+	return &UserPool{
+		TokenValidity: DefaultTokenValidity,
+		id:            userpoolID,
+		clientID:      clientID,
+		issuer:        fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/%s", split[0], userpoolID),
+		kid:           uuid.New().String(),
+		key:           key,
+		users:         make(map[string]*User),
+		srpSessions:   make(map[string]*SRPServer),
+		sessions:      make(map[string]*challengeSession),
+		refreshTokens: make(map[string]string),
+		accessTokens:  make(map[string]*accessToken),
+	}, nil
+}
+
+// ID returns the user pool ID.
+func (up *UserPool) ID() string {
+	return up.id
+}
+
+// ClientID returns the app client ID.
+func (up *UserPool) ClientID() string {
+	return up.clientID
+}
+
+// Issuer returns the issuer (iss) of the tokens issued by the user pool.
+func (up *UserPool) Issuer() string {
+	up.mu.Lock()
+	defer up.mu.Unlock()
+	return up.issuer
+}
+
+func (up *UserPool) setIssuer(issuer string) {
+	up.mu.Lock()
+	defer up.mu.Unlock()
+	up.issuer = issuer
+}
+
+// AddUser adds a user to the pool, replacing any existing user with the same username.
+func (up *UserPool) AddUser(user User) error {
+	salt, err := NewSRPSalt()
+	if err != nil {
+		return err
+	}
+
+	if user.Status == "" {
+		user.Status = cip.UserStatusTypeConfirmed
+	}
+	user = user.copy()
+	now := time.Now()
+	for i := range user.Devices {
+		user.Devices[i].createDate = now
+		user.Devices[i].lastModifiedDate = now
+	}
+	user.mfaDisabled = false
+	user.sub = uuid.New().String()
+	user.salt = salt
+	user.verifier = SRPVerifier(up.userpoolName(), user.Username, user.Password, salt)
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+	up.users[user.Username] = &user
+
+	return nil
+}
+
+// User returns a copy of the user with the given username.
+func (up *UserPool) User(username string) (User, bool) {
+	up.mu.Lock()
+	defer up.mu.Unlock()
+
+	user, exists := up.users[username]
+	if !exists {
+		return User{}, false
+	}
+
+	return user.copy(), true
+}
+
+func (up *UserPool) userpoolName() string {
+	return strings.SplitN(up.id, "_", 2)[1]
+}
+
+// setPassword updates the password and verifier of the user. Must be called with mu held.
+func (up *UserPool) setPassword(user *User, password string) error {
+	salt, err := NewSRPSalt()
+	if err != nil {
+		return err
+	}
+
+	user.Password = password
+	user.salt = salt
+	user.verifier = SRPVerifier(up.userpoolName(), user.Username, password, salt)
+
+	return nil
+}
+
+// signOut invalidates all access and refresh tokens issued to the user. Must be called with mu held.
+func (up *UserPool) signOut(username string) {
+	for token, owner := range up.refreshTokens {
+		if owner == username {
+			delete(up.refreshTokens, token)
+		}
+	}
+	for token, at := range up.accessTokens {
+		if at.username == username {
+			delete(up.accessTokens, token)
+		}
+	}
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating random value: %v", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,141 @@
+// Package cli holds the configuration and token cache shared by the commands in cmd.
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/larwef/cognito/client"
+)
+
+// Config holds the user pool and user to get tokens for. The password is only read from the environment so it does
+// not show up in the process list.
+type Config struct {
+	UserpoolID string
+	ClientID   string
+	Username   string
+	Password   string
+	Endpoint   string
+	Cache      string
+	NoCache    bool
+}
+
+// RegisterFlags registers flags for the Config in fs, with defaults read from the environment using getenv.
+func (c *Config) RegisterFlags(fs *flag.FlagSet, getenv func(string) string) {
+	c.Password = getenv("COGNITO_PASSWORD")
+
+	fs.StringVar(&c.UserpoolID, "userpool", getenv("COGNITO_USERPOOL_ID"), "user pool ID. Defaults to $COGNITO_USERPOOL_ID")
+	fs.StringVar(&c.ClientID, "client-id", getenv("COGNITO_CLIENT_ID"), "app client ID. Defaults to $COGNITO_CLIENT_ID")
+	fs.StringVar(&c.Username, "username", getenv("COGNITO_USERNAME"), "username. Defaults to $COGNITO_USERNAME. The password is read from $COGNITO_PASSWORD")
+	fs.StringVar(&c.Endpoint, "endpoint", getenv("COGNITO_ENDPOINT"), "Cognito endpoint, eg. an emulator. Defaults to $COGNITO_ENDPOINT")
+	fs.StringVar(&c.Cache, "cache", "", "token cache file. Defaults to a file in the user cache directory")
+	fs.BoolVar(&c.NoCache, "no-cache", false, "do not read or write the token cache")
+}
+
+// Validate checks that the required values are set and sets the default cache file.
+func (c *Config) Validate() error {
+	if c.UserpoolID == "" || c.ClientID == "" || c.Username == "" {
+		return errors.New("user pool ID, client ID and username are required")
+	}
+
+	if c.Cache == "" && !c.NoCache {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return fmt.Errorf("error finding cache directory: %v. Use -cache or -no-cache", err)
+		}
+		c.Cache = filepath.Join(dir, "cognito-token", cacheFile(c.UserpoolID, c.ClientID, c.Username))
+	}
+
+	return nil
+}
+
+// cacheFile returns the name of the cache file for the user. The username is hashed, since it may contain path
+// separators or other characters which are not valid in file names.
+func cacheFile(userpoolID, clientID, username string) string {
+	h := sha256.Sum256([]byte(username))
+	return url.PathEscape(userpoolID+"_"+clientID) + "_" + hex.EncodeToString(h[:]) + ".json"
+}
+
+// Token returns a valid Token. A cached Token is used until it expires and is then refreshed with its refresh token.
+// The password is only used when there is no usable cached Token.
+func (c *Config) Token(ctx context.Context) (*client.Token, error) {
+	ts, err := client.NewTokenSource(&client.Config{
+		UserpoolID:  c.UserpoolID,
+		ClientID:    c.ClientID,
+		Username:    c.Username,
+		Password:    c.Password,
+		Lightweight: true,
+		Endpoint:    c.Endpoint,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating token source: %v", err)
+	}
+
+	cached := !c.NoCache && loadToken(ts, c.Cache)
+	if !cached && c.Password == "" {
+		return nil, errors.New("no cached token and COGNITO_PASSWORD is not set")
+	}
+
+	tkn, err := ts.Token(ctx)
+	if err != nil && cached && c.Password != "" {
+		// The refresh token may have expired or been revoked. Authenticate again.
+		ts.SetToken(&client.Token{})
+		tkn, err = ts.Token(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.NoCache {
+		if err := saveToken(c.Cache, tkn); err != nil {
+			return nil, err
+		}
+	}
+
+	return tkn, nil
+}
+
+func loadToken(ts *client.TokenSource, path string) bool {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	var tkn client.Token
+	if err := json.Unmarshal(b, &tkn); err != nil {
+		return false
+	}
+
+	if !tkn.Valid() && tkn.RefreshToken == "" {
+		return false
+	}
+
+	ts.SetToken(&tkn)
+	return true
+}
+
+func saveToken(path string, tkn *client.Token) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating cache directory: %v", err)
+	}
+
+	b, err := tkn.Persist()
+	if err != nil {
+		return fmt.Errorf("error marshalling token: %v", err)
+	}
+
+	if err := ioutil.WriteFile(path, b, 0600); err != nil {
+		return fmt.Errorf("error writing cache: %v", err)
+	}
+
+	return nil
+}
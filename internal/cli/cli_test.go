@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCacheFile(t *testing.T) {
+	for _, username := range []string{"user", "../../.ssh/authorized_keys", `user\name`, "user/name"} {
+		name := cacheFile("eu-west-1_pool", "clientId", username)
+
+		if filepath.Base(name) != name || strings.ContainsAny(name, `/\`) {
+			t.Errorf("Unexpected value: %s for username %s. Expected a file name", name, username)
+		}
+
+		if !strings.HasPrefix(name, "eu-west-1_pool_clientId_") || !strings.HasSuffix(name, ".json") {
+			t.Errorf("Unexpected value: %s for username %s", name, username)
+		}
+	}
+
+	if cacheFile("eu-west-1_pool", "clientId", "user1") == cacheFile("eu-west-1_pool", "clientId", "user2") {
+		t.Error("Expected different cache files for different users")
+	}
+}
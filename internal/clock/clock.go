@@ -0,0 +1,26 @@
+// Package clock holds the Clock shared by the client and verifier packages.
+package clock
+
+import "time"
+
+// Clock tells the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// Func is an adapter to allow the use of ordinary functions as Clocks.
+type Func func() time.Time
+
+// Now returns f().
+func (f Func) Now() time.Time {
+	return f()
+}
+
+// Now returns the time of c, or of the system clock if c is nil.
+func Now(c Clock) time.Time {
+	if c == nil {
+		return time.Now()
+	}
+
+	return c.Now()
+}
@@ -0,0 +1,17 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNow(t *testing.T) {
+	now := time.Date(2019, 4, 19, 20, 0, 0, 0, time.UTC)
+	if n := Now(Func(func() time.Time { return now })); !n.Equal(now) {
+		t.Errorf("Unexpected value: %v. Expected: %v", n, now)
+	}
+
+	if n := Now(nil); n.Sub(time.Now()) > time.Second {
+		t.Errorf("Unexpected value: %v. Expected the system time", n)
+	}
+}
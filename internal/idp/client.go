@@ -0,0 +1,195 @@
+// Package idp is a minimal client for the Cognito Identity Provider JSON API. It speaks the
+// AWSCognitoIdentityProviderService protocol directly over a http.Client using encoding/json, without aws-sdk-go, and
+// only implements the unauthenticated operations used by the client package.
+//
+// Inputs and outputs are plain structs holding the fields used by the client package.
+package idp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const (
+	targetPrefix = "AWSCognitoIdentityProviderService"
+	contentType  = "application/x-amz-json-1.1"
+)
+
+// Error is returned when Cognito responds with an error. Code is the name of the exception, eg.
+// NotAuthorizedException, the same as the error codes of aws-sdk-go.
+type Error struct {
+	Code       string
+	Message    string
+	StatusCode int
+	RequestID  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s (status code: %d, request id: %s)", e.Code, e.Message, e.StatusCode, e.RequestID)
+}
+
+// Client calls the Cognito Identity Provider JSON API.
+type Client struct {
+	// Endpoint is the URL the requests are sent to.
+	Endpoint string
+
+	// HTTPClient is used to send the requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// New returns a new Client for the Cognito endpoint in region.
+func New(region string, httpClient *http.Client) *Client {
+	return &Client{
+		Endpoint:   fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/", region),
+		HTTPClient: httpClient,
+	}
+}
+
+type errorResponse struct {
+	Code    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+// Call sends the operation with input to Cognito and decodes the response into output.
+func (c *Client) Call(ctx context.Context, operation string, input, output interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("error encoding %s request: %v", operation, err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating %s request: %v", operation, err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Target", targetPrefix+"."+operation)
+
+	res, err := c.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending %s request: %v", operation, err)
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("error reading %s response: %v", operation, err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return decodeError(res, b)
+	}
+
+	if err := json.Unmarshal(b, output); err != nil {
+		return fmt.Errorf("error decoding %s response: %v", operation, err)
+	}
+
+	return nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func decodeError(res *http.Response, body []byte) error {
+	e := &Error{
+		StatusCode: res.StatusCode,
+		RequestID:  res.Header.Get("X-Amzn-Requestid"),
+	}
+
+	var errRes errorResponse
+	if err := json.Unmarshal(body, &errRes); err != nil || errRes.Code == "" {
+		e.Code = "UnknownError"
+		e.Message = res.Status
+		return e
+	}
+
+	// The type may be prefixed with a namespace, eg. com.amazonaws.cognito#NotAuthorizedException.
+	codes := strings.SplitN(errRes.Code, "#", 2)
+	e.Code = codes[len(codes)-1]
+	e.Message = errRes.Message
+	return e
+}
+
+// InitiateAuth calls the InitiateAuth operation.
+func (c *Client) InitiateAuth(ctx context.Context, input *InitiateAuthInput) (*AuthOutput, error) {
+	output := &AuthOutput{}
+	return output, c.Call(ctx, "InitiateAuth", input, output)
+}
+
+// RespondToAuthChallenge calls the RespondToAuthChallenge operation.
+func (c *Client) RespondToAuthChallenge(ctx context.Context, input *RespondToAuthChallengeInput) (*AuthOutput, error) {
+	output := &AuthOutput{}
+	return output, c.Call(ctx, "RespondToAuthChallenge", input, output)
+}
+
+// ChangePassword calls the ChangePassword operation.
+func (c *Client) ChangePassword(ctx context.Context, input *ChangePasswordInput) error {
+	return c.Call(ctx, "ChangePassword", input, &struct{}{})
+}
+
+// GetUser calls the GetUser operation.
+func (c *Client) GetUser(ctx context.Context, input *AccessTokenInput) (*GetUserOutput, error) {
+	output := &GetUserOutput{}
+	return output, c.Call(ctx, "GetUser", input, output)
+}
+
+// UpdateUserAttributes calls the UpdateUserAttributes operation.
+func (c *Client) UpdateUserAttributes(ctx context.Context, input *UpdateUserAttributesInput) error {
+	return c.Call(ctx, "UpdateUserAttributes", input, &struct{}{})
+}
+
+// VerifyUserAttribute calls the VerifyUserAttribute operation.
+func (c *Client) VerifyUserAttribute(ctx context.Context, input *VerifyUserAttributeInput) error {
+	return c.Call(ctx, "VerifyUserAttribute", input, &struct{}{})
+}
+
+// DeleteUserAttributes calls the DeleteUserAttributes operation.
+func (c *Client) DeleteUserAttributes(ctx context.Context, input *DeleteUserAttributesInput) error {
+	return c.Call(ctx, "DeleteUserAttributes", input, &struct{}{})
+}
+
+// SetUserMFAPreference calls the SetUserMFAPreference operation.
+func (c *Client) SetUserMFAPreference(ctx context.Context, input *SetUserMFAPreferenceInput) error {
+	return c.Call(ctx, "SetUserMFAPreference", input, &struct{}{})
+}
+
+// GlobalSignOut calls the GlobalSignOut operation.
+func (c *Client) GlobalSignOut(ctx context.Context, input *AccessTokenInput) error {
+	return c.Call(ctx, "GlobalSignOut", input, &struct{}{})
+}
+
+// ListDevices calls the ListDevices operation.
+func (c *Client) ListDevices(ctx context.Context, input *ListDevicesInput) (*ListDevicesOutput, error) {
+	output := &ListDevicesOutput{}
+	return output, c.Call(ctx, "ListDevices", input, output)
+}
+
+// GetDevice calls the GetDevice operation.
+func (c *Client) GetDevice(ctx context.Context, input *DeviceInput) (*GetDeviceOutput, error) {
+	output := &GetDeviceOutput{}
+	return output, c.Call(ctx, "GetDevice", input, output)
+}
+
+// UpdateDeviceStatus calls the UpdateDeviceStatus operation.
+func (c *Client) UpdateDeviceStatus(ctx context.Context, input *UpdateDeviceStatusInput) error {
+	return c.Call(ctx, "UpdateDeviceStatus", input, &struct{}{})
+}
+
+// ForgetDevice calls the ForgetDevice operation.
+func (c *Client) ForgetDevice(ctx context.Context, input *DeviceInput) error {
+	return c.Call(ctx, "ForgetDevice", input, &struct{}{})
+}
+
+// RevokeToken calls the RevokeToken operation.
+func (c *Client) RevokeToken(ctx context.Context, input *RevokeTokenInput) error {
+	return c.Call(ctx, "RevokeToken", input, &struct{}{})
+}
@@ -0,0 +1,119 @@
+package idp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_InitiateAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target := r.Header.Get("X-Amz-Target"); target != "AWSCognitoIdentityProviderService.InitiateAuth" {
+			t.Errorf("Unexpected X-Amz-Target: %s", target)
+		}
+
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-amz-json-1.1" {
+			t.Errorf("Unexpected Content-Type: %s", ct)
+		}
+
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Error decoding request body: %v", err)
+		}
+		if body["AuthFlow"] != "REFRESH_TOKEN_AUTH" || body["ClientId"] != "clientId" {
+			t.Errorf("Unexpected request body: %v", body)
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Write([]byte(`{"AuthenticationResult":{"AccessToken":"AccessToken","ExpiresIn":3600,"IdToken":"IDToken","TokenType":"Bearer"},"ChallengeParameters":{}}`))
+	}))
+	defer server.Close()
+
+	c := &Client{Endpoint: server.URL}
+	res, err := c.InitiateAuth(context.Background(), &InitiateAuthInput{
+		AuthFlow: "REFRESH_TOKEN_AUTH",
+		AuthParameters: map[string]string{
+			"REFRESH_TOKEN": "RefreshToken",
+		},
+		ClientID: "clientId",
+	})
+	if err != nil {
+		t.Fatalf("InitiateAuth returned an error: %v", err)
+	}
+
+	if res.AuthenticationResult.AccessToken != "AccessToken" {
+		t.Error("AccessToken has unexpected value")
+	}
+
+	if res.AuthenticationResult.ExpiresIn != 3600 {
+		t.Error("ExpiresIn has unexpected value")
+	}
+}
+
+func TestClient_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amzn-Requestid", "requestId")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"com.amazonaws.cognito#NotAuthorizedException","message":"Incorrect username or password."}`))
+	}))
+	defer server.Close()
+
+	c := &Client{Endpoint: server.URL}
+	_, err := c.InitiateAuth(context.Background(), &InitiateAuthInput{})
+	if err == nil {
+		t.Fatal("Expected InitiateAuth to return an error")
+	}
+
+	idpErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Expected error of type *Error but got: %T", err)
+	}
+
+	if idpErr.Code != "NotAuthorizedException" {
+		t.Errorf("Unexpected error code: %s. Expected: %s", idpErr.Code, "NotAuthorizedException")
+	}
+
+	if idpErr.Message != "Incorrect username or password." {
+		t.Errorf("Unexpected error message: %s", idpErr.Message)
+	}
+
+	if idpErr.StatusCode != http.StatusBadRequest || idpErr.RequestID != "requestId" {
+		t.Errorf("Unexpected status code %d or request ID %s", idpErr.StatusCode, idpErr.RequestID)
+	}
+}
+
+func TestClient_Error_UndecodableBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	c := &Client{Endpoint: server.URL}
+	_, err := c.GetUser(context.Background(), &AccessTokenInput{AccessToken: "AccessToken"})
+
+	idpErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Expected error of type *Error but got: %T", err)
+	}
+
+	if idpErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Unexpected status code %d", idpErr.StatusCode)
+	}
+}
+
+func TestClient_CanceledContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Request should not be sent with a canceled context")
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := &Client{Endpoint: server.URL}
+	if err := c.GlobalSignOut(ctx, &AccessTokenInput{}); err == nil {
+		t.Error("Expected GlobalSignOut to return an error")
+	}
+}
@@ -0,0 +1,137 @@
+package idp
+
+// InitiateAuthInput is the input of InitiateAuth.
+type InitiateAuthInput struct {
+	AuthFlow       string            `json:"AuthFlow"`
+	AuthParameters map[string]string `json:"AuthParameters,omitempty"`
+	ClientID       string            `json:"ClientId"`
+}
+
+// RespondToAuthChallengeInput is the input of RespondToAuthChallenge.
+type RespondToAuthChallengeInput struct {
+	ChallengeName      string            `json:"ChallengeName"`
+	ChallengeResponses map[string]string `json:"ChallengeResponses,omitempty"`
+	ClientID           string            `json:"ClientId"`
+	Session            string            `json:"Session,omitempty"`
+}
+
+// AuthOutput is the output of InitiateAuth and RespondToAuthChallenge.
+type AuthOutput struct {
+	AuthenticationResult *AuthenticationResult `json:"AuthenticationResult"`
+	ChallengeName        string                `json:"ChallengeName"`
+	ChallengeParameters  map[string]string     `json:"ChallengeParameters"`
+	Session              string                `json:"Session"`
+}
+
+// AuthenticationResult holds the tokens issued by a successful authentication.
+type AuthenticationResult struct {
+	AccessToken  string `json:"AccessToken"`
+	ExpiresIn    int64  `json:"ExpiresIn"`
+	IDToken      string `json:"IdToken"`
+	RefreshToken string `json:"RefreshToken"`
+	TokenType    string `json:"TokenType"`
+}
+
+// AccessTokenInput is the input of the operations only taking the access token, GetUser and GlobalSignOut.
+type AccessTokenInput struct {
+	AccessToken string `json:"AccessToken"`
+}
+
+// ChangePasswordInput is the input of ChangePassword.
+type ChangePasswordInput struct {
+	AccessToken      string `json:"AccessToken"`
+	PreviousPassword string `json:"PreviousPassword"`
+	ProposedPassword string `json:"ProposedPassword"`
+}
+
+// Attribute is a user or device attribute.
+type Attribute struct {
+	Name  string `json:"Name"`
+	Value string `json:"Value"`
+}
+
+// GetUserOutput is the output of GetUser.
+type GetUserOutput struct {
+	Username            string      `json:"Username"`
+	UserAttributes      []Attribute `json:"UserAttributes"`
+	PreferredMfaSetting string      `json:"PreferredMfaSetting"`
+	UserMFASettingList  []string    `json:"UserMFASettingList"`
+}
+
+// UpdateUserAttributesInput is the input of UpdateUserAttributes.
+type UpdateUserAttributesInput struct {
+	AccessToken    string      `json:"AccessToken"`
+	UserAttributes []Attribute `json:"UserAttributes"`
+}
+
+// VerifyUserAttributeInput is the input of VerifyUserAttribute.
+type VerifyUserAttributeInput struct {
+	AccessToken   string `json:"AccessToken"`
+	AttributeName string `json:"AttributeName"`
+	Code          string `json:"Code"`
+}
+
+// DeleteUserAttributesInput is the input of DeleteUserAttributes.
+type DeleteUserAttributesInput struct {
+	AccessToken        string   `json:"AccessToken"`
+	UserAttributeNames []string `json:"UserAttributeNames"`
+}
+
+// MFASettings holds the preference for a single MFA method. Both fields are always sent, since false is meaningful.
+type MFASettings struct {
+	Enabled      bool `json:"Enabled"`
+	PreferredMfa bool `json:"PreferredMfa"`
+}
+
+// SetUserMFAPreferenceInput is the input of SetUserMFAPreference. A nil setting leaves that MFA method unchanged.
+type SetUserMFAPreferenceInput struct {
+	AccessToken              string       `json:"AccessToken"`
+	SMSMfaSettings           *MFASettings `json:"SMSMfaSettings,omitempty"`
+	SoftwareTokenMfaSettings *MFASettings `json:"SoftwareTokenMfaSettings,omitempty"`
+}
+
+// ListDevicesInput is the input of ListDevices.
+type ListDevicesInput struct {
+	AccessToken     string `json:"AccessToken"`
+	Limit           int64  `json:"Limit,omitempty"`
+	PaginationToken string `json:"PaginationToken,omitempty"`
+}
+
+// ListDevicesOutput is the output of ListDevices.
+type ListDevicesOutput struct {
+	Devices         []Device `json:"Devices"`
+	PaginationToken string   `json:"PaginationToken"`
+}
+
+// Device describes a remembered device. The dates are seconds since the Unix epoch.
+type Device struct {
+	DeviceKey                   string      `json:"DeviceKey"`
+	DeviceAttributes            []Attribute `json:"DeviceAttributes"`
+	DeviceCreateDate            float64     `json:"DeviceCreateDate"`
+	DeviceLastAuthenticatedDate float64     `json:"DeviceLastAuthenticatedDate"`
+	DeviceLastModifiedDate      float64     `json:"DeviceLastModifiedDate"`
+}
+
+// DeviceInput is the input of the operations on a single device, GetDevice and ForgetDevice.
+type DeviceInput struct {
+	AccessToken string `json:"AccessToken"`
+	DeviceKey   string `json:"DeviceKey"`
+}
+
+// GetDeviceOutput is the output of GetDevice.
+type GetDeviceOutput struct {
+	Device *Device `json:"Device"`
+}
+
+// UpdateDeviceStatusInput is the input of UpdateDeviceStatus.
+type UpdateDeviceStatusInput struct {
+	AccessToken            string `json:"AccessToken"`
+	DeviceKey              string `json:"DeviceKey"`
+	DeviceRememberedStatus string `json:"DeviceRememberedStatus"`
+}
+
+// RevokeTokenInput is the input of RevokeToken.
+type RevokeTokenInput struct {
+	ClientID string `json:"ClientId"`
+	Token    string `json:"Token"`
+}
@@ -0,0 +1,108 @@
+// Package logging holds the Logger shared by the client and verifier packages, and the redaction applied before
+// anything reaches it.
+package logging
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the values of sensitive keys.
+const Redacted = "[REDACTED]"
+
+// Logger is a structured logger taking a message followed by alternating keys and values. It is implemented by
+// *slog.Logger.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// New returns a Logger redacting sensitive values before passing them on to l. If l is nil, nothing is logged.
+func New(l Logger) Logger {
+	if l == nil {
+		return nopLogger{}
+	}
+
+	return redactingLogger{l: l}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, args ...interface{}) {}
+func (nopLogger) Info(msg string, args ...interface{})  {}
+func (nopLogger) Warn(msg string, args ...interface{})  {}
+func (nopLogger) Error(msg string, args ...interface{}) {}
+
+// redactingLogger redacts sensitive values before passing them on to the wrapped Logger.
+type redactingLogger struct {
+	l Logger
+}
+
+func (r redactingLogger) Debug(msg string, args ...interface{}) {
+	r.l.Debug(msg, Redact(args)...)
+}
+
+func (r redactingLogger) Info(msg string, args ...interface{}) {
+	r.l.Info(msg, Redact(args)...)
+}
+
+func (r redactingLogger) Warn(msg string, args ...interface{}) {
+	r.l.Warn(msg, Redact(args)...)
+}
+
+func (r redactingLogger) Error(msg string, args ...interface{}) {
+	r.l.Error(msg, Redact(args)...)
+}
+
+// sensitive are substrings of keys whose values are never logged.
+var sensitive = []string{"password", "token", "secret", "authorization", "signature", "session"}
+
+// jwt matches JWT shaped strings: three base64url segments separated by dots, starting with an encoded JSON header.
+var jwt = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+// Redact returns a copy of the key-value pairs in args with the values of sensitive keys, eg. "password" or
+// "refresh_token", replaced by Redacted. Keys are compared case insensitively. JWTs in string and error values are
+// replaced by Redacted under any key.
+func Redact(args []interface{}) []interface{} {
+	redacted := make([]interface{}, len(args))
+	copy(redacted, args)
+
+	for i := 0; i+1 < len(redacted); i += 2 {
+		key, ok := redacted[i].(string)
+		if ok && isSensitive(key) {
+			redacted[i+1] = Redacted
+			continue
+		}
+
+		redacted[i+1] = redactJWT(redacted[i+1])
+	}
+
+	return redacted
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitive {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactJWT replaces JWTs in string and error values. Errors containing a JWT are replaced by their redacted message.
+func redactJWT(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return jwt.ReplaceAllString(v, Redacted)
+	case error:
+		if msg := v.Error(); jwt.MatchString(msg) {
+			return jwt.ReplaceAllString(msg, Redacted)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,79 @@
+package srp
+
+import (
+	"io"
+	"math/big"
+)
+
+// Client is the client side of an SRP-6a exchange.
+type Client struct {
+	params *Params
+	a      *big.Int
+	xA     *big.Int
+
+	paddedA []byte
+}
+
+// NewClient returns a Client with a private key read from random.
+func NewClient(params *Params, random io.Reader) (*Client, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	a, err := GeneratePrivateKey(params, random)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClientWithKey(params, a)
+}
+
+// NewClientWithKey returns a Client with the private key a. It is mostly useful for reproducing test vectors.
+func NewClientWithKey(params *Params, a *big.Int) (*Client, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := params.checkPrivateKey(a); err != nil {
+		return nil, err
+	}
+
+	xA := big.NewInt(0).Exp(params.Group.G, a, params.Group.N)
+	return &Client{
+		params:  params,
+		a:       a,
+		xA:      xA,
+		paddedA: params.Pad(xA),
+	}, nil
+}
+
+// A returns the public value A = g^a sent to the server.
+func (c *Client) A() *big.Int {
+	return c.xA
+}
+
+// ComputeSecret computes the shared secret S = (B - kg^x)^(a + ux) from the salt and B received from the server.
+func (c *Client) ComputeSecret(identity, password string, salt []byte, xB *big.Int) (*Session, error) {
+	p := c.params
+	if err := p.checkPublicKey(xB); err != nil {
+		return nil, err
+	}
+
+	u := big.NewInt(0).SetBytes(p.H(c.paddedA, p.Pad(xB)))
+	if u.Sign() == 0 {
+		return nil, ErrInvalidU
+	}
+
+	x := p.X(identity, password, salt)
+
+	base := new(big.Int).Exp(p.Group.G, x, p.Group.N) // g^x
+	base.Mul(base, p.k)                               // kg^x
+	base.Sub(xB, base)                                // B - kg^x
+	base.Mod(base, p.Group.N)
+
+	exp := x.Mul(u, x)             // ux
+	exp.Add(exp, c.a)              // a + ux
+	base.Exp(base, exp, p.Group.N) // (B - kg^x)^(a + ux)
+
+	return &Session{params: p, u: u, xS: base}, nil
+}
@@ -0,0 +1,53 @@
+package srp
+
+import (
+	"crypto"
+	"crypto/hmac"
+	_ "crypto/sha256" // Cognito uses SHA-256.
+	"math/big"
+)
+
+const (
+	cognitoDerivedKeyInfo = "Caldera Derived Key"
+	cognitoDerivedKeySize = 16
+)
+
+// Cognito holds the parameters used by Amazon Cognito user pools: the 3072-bit group from RFC 5054 with generator 2,
+// SHA-256 and signed padding.
+//
+// The identity used with Cognito is the user pool name (the part of the user pool ID after the underscore) followed
+// by the username, and the salt is CognitoSalt applied to the SALT challenge parameter.
+var Cognito = &Params{
+	Group:   &Group{N: Group3072.N, G: big.NewInt(2)},
+	Hash:    crypto.SHA256,
+	Padding: PadSigned,
+}
+
+// CognitoSalt encodes the salt received from Cognito as the bytes used when computing x.
+func CognitoSalt(salt *big.Int) []byte {
+	return Cognito.Pad(salt)
+}
+
+// CognitoKey derives the 16 byte key used to sign the password claim from the session, using HKDF with the padded
+// shared secret as input keying material, the padded u as salt and "Caldera Derived Key" as info.
+func CognitoKey(session *Session) []byte {
+	p := session.params
+	mac := hmac.New(p.Hash.New, p.Pad(session.u))
+	mac.Write(p.Pad(session.xS))
+	prk := mac.Sum(nil)
+
+	mac = hmac.New(p.Hash.New, prk)
+	mac.Write([]byte(cognitoDerivedKeyInfo))
+	mac.Write([]byte{1})
+	return mac.Sum(nil)[:cognitoDerivedKeySize]
+}
+
+// CognitoClaimSignature returns the PASSWORD_CLAIM_SIGNATURE for the PASSWORD_VERIFIER challenge.
+func CognitoClaimSignature(key []byte, userpoolName, username string, secretBlock []byte, timestamp string) []byte {
+	mac := hmac.New(Cognito.Hash.New, key)
+	mac.Write([]byte(userpoolName))
+	mac.Write([]byte(username))
+	mac.Write(secretBlock)
+	mac.Write([]byte(timestamp))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,72 @@
+package srp
+
+import (
+	"io"
+	"math/big"
+)
+
+// Server is the server side of an SRP-6a exchange.
+type Server struct {
+	params *Params
+	v      *big.Int
+	b      *big.Int
+	xB     *big.Int
+}
+
+// NewServer returns a Server for the password verifier v with a private key read from random.
+func NewServer(params *Params, v *big.Int, random io.Reader) (*Server, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	b, err := GeneratePrivateKey(params, random)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewServerWithKey(params, v, b)
+}
+
+// NewServerWithKey returns a Server for the password verifier v with the private key b. It is mostly useful for
+// reproducing test vectors.
+func NewServerWithKey(params *Params, v, b *big.Int) (*Server, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := params.checkPrivateKey(b); err != nil {
+		return nil, err
+	}
+
+	kv := new(big.Int).Mul(params.k, v)
+	xB := new(big.Int).Exp(params.Group.G, b, params.Group.N)
+	xB.Add(xB, kv)
+	xB.Mod(xB, params.Group.N)
+
+	return &Server{params: params, v: v, b: b, xB: xB}, nil
+}
+
+// B returns the public value B = kv + g^b sent to the client.
+func (s *Server) B() *big.Int {
+	return s.xB
+}
+
+// ComputeSecret computes the shared secret S = (Av^u)^b from A received from the client.
+func (s *Server) ComputeSecret(xA *big.Int) (*Session, error) {
+	p := s.params
+	if xA == nil || new(big.Int).Mod(xA, p.Group.N).Sign() == 0 {
+		return nil, ErrInvalidPublicKey
+	}
+
+	u := p.U(xA, s.xB)
+	if u.Sign() == 0 {
+		return nil, ErrInvalidU
+	}
+
+	xS := new(big.Int).Exp(s.v, u, p.Group.N) // v^u
+	xS.Mul(xS, xA)                            // Av^u
+	xS.Mod(xS, p.Group.N)
+	xS.Exp(xS, s.b, p.Group.N) // (Av^u)^b
+
+	return &Session{params: p, u: u, xS: xS}, nil
+}
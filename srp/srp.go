@@ -0,0 +1,205 @@
+// Package srp implements the Secure Remote Password protocol, version 6a, as described in RFC 2945 and RFC 5054.
+//
+// The protocol is parameterized by a Params value holding the group, the hash function and the padding used when
+// hashing integers. RFC5054 returns the parameters from RFC 5054 for a group and hash, and Cognito holds the
+// parameters used by Amazon Cognito user pools together with helpers for Cognito's key derivation and password claim.
+//
+// A Client computes A and, given the salt and B from the server, the shared secret. A Server computes B from a
+// password verifier and, given A from the client, the same shared secret.
+package srp
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+)
+
+var (
+	// ErrInvalidPrivateKey is returned when a private key is not in the range [1, N-1].
+	ErrInvalidPrivateKey = errors.New("private key must be in the range [1, N-1]")
+
+	// ErrInvalidPublicKey is returned when A or B is not in the range [1, N-1], ie. A mod N or B mod N is 0.
+	ErrInvalidPublicKey = errors.New("public key must be in the range [1, N-1]")
+
+	// ErrInvalidU is returned when the scrambling parameter u = H(A | B) is 0.
+	ErrInvalidU = errors.New("scrambling parameter u is 0")
+
+	// ErrHashUnavailable is returned when the hash function of the Params is not linked into the binary.
+	ErrHashUnavailable = errors.New("hash function is not available")
+)
+
+// Padding describes how integers are encoded before they are hashed.
+type Padding int
+
+const (
+	// PadToGroup left pads integers with zeros to the length of N, as PAD() in RFC 5054.
+	PadToGroup Padding = iota
+
+	// PadSigned encodes integers as the shortest big-endian two's complement representation, adding a leading zero
+	// byte when the most significant bit is set. This is the encoding used by Cognito.
+	PadSigned
+)
+
+// Params holds the parameters of an SRP-6a exchange. Client and Server must use the same Params. Values derived from
+// the group are computed on first use, so the fields must not be modified after the Params have been used.
+type Params struct {
+	Group   *Group
+	Hash    crypto.Hash
+	Padding Padding
+
+	once sync.Once
+	k    *big.Int
+	nLen int
+}
+
+// RFC5054 returns the parameters described in RFC 5054 for the group and hash.
+func RFC5054(group *Group, hash crypto.Hash) *Params {
+	return &Params{Group: group, Hash: hash, Padding: PadToGroup}
+}
+
+func (p *Params) validate() error {
+	if p.Group == nil || p.Group.N == nil || p.Group.G == nil {
+		return fmt.Errorf("params have no group")
+	}
+
+	if !p.Hash.Available() {
+		return ErrHashUnavailable
+	}
+
+	p.once.Do(p.precompute)
+	return nil
+}
+
+// precompute computes the values derived from the group which are used in every exchange.
+func (p *Params) precompute() {
+	p.nLen = (p.Group.N.BitLen() + 7) / 8
+	p.k = big.NewInt(0).SetBytes(p.H(p.Pad(p.Group.N), p.Pad(p.Group.G)))
+}
+
+// Pad encodes x according to the Padding of the Params.
+func (p *Params) Pad(x *big.Int) []byte {
+	b := x.Bytes()
+	switch p.Padding {
+	case PadSigned:
+		if len(b) == 0 || b[0]&0x80 != 0 {
+			return append([]byte{0}, b...)
+		}
+		return b
+	default:
+		size := p.nLen
+		if size == 0 {
+			size = (p.Group.N.BitLen() + 7) / 8
+		}
+		if len(b) >= size {
+			return b
+		}
+		padded := make([]byte, size)
+		copy(padded[size-len(b):], b)
+		return padded
+	}
+}
+
+// H hashes the concatenation of buf using the hash function of the Params.
+func (p *Params) H(buf ...[]byte) []byte {
+	h := p.Hash.New()
+	for _, elem := range buf {
+		h.Write(elem)
+	}
+
+	return h.Sum(nil)
+}
+
+// K returns the multiplier parameter k = H(N | PAD(g)). The returned value must not be modified.
+func (p *Params) K() *big.Int {
+	p.once.Do(p.precompute)
+	return p.k
+}
+
+// X returns the private key x = H(salt | H(identity | ":" | password)).
+func (p *Params) X(identity, password string, salt []byte) *big.Int {
+	h := p.Hash.New()
+	io.WriteString(h, identity)
+	io.WriteString(h, ":")
+	io.WriteString(h, password)
+	inner := h.Sum(nil)
+
+	h.Reset()
+	h.Write(salt)
+	h.Write(inner)
+	return big.NewInt(0).SetBytes(h.Sum(inner[:0]))
+}
+
+// U returns the scrambling parameter u = H(PAD(A) | PAD(B)).
+func (p *Params) U(xA, xB *big.Int) *big.Int {
+	return big.NewInt(0).SetBytes(p.H(p.Pad(xA), p.Pad(xB)))
+}
+
+// Verifier returns the password verifier v = g^x stored by the server.
+func Verifier(params *Params, identity, password string, salt []byte) (*big.Int, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	return big.NewInt(0).Exp(params.Group.G, params.X(identity, password, salt), params.Group.N), nil
+}
+
+// GeneratePrivateKey reads a private key in the range [1, N-1] from random.
+func GeneratePrivateKey(params *Params, random io.Reader) (*big.Int, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, params.nLen)
+	if _, err := io.ReadFull(random, b); err != nil {
+		return nil, fmt.Errorf("error reading random bytes: %v", err)
+	}
+
+	key := big.NewInt(0).SetBytes(b)
+	key.Mod(key, params.Group.N)
+	if key.Sign() == 0 {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	return key, nil
+}
+
+func (p *Params) checkPrivateKey(key *big.Int) error {
+	if key == nil || key.Sign() <= 0 || key.Cmp(p.Group.N) >= 0 {
+		return ErrInvalidPrivateKey
+	}
+
+	return nil
+}
+
+func (p *Params) checkPublicKey(key *big.Int) error {
+	if key == nil || key.Sign() <= 0 || key.Cmp(p.Group.N) >= 0 {
+		return ErrInvalidPublicKey
+	}
+
+	return nil
+}
+
+// Session holds the shared secret computed by a Client or Server.
+type Session struct {
+	params *Params
+	u      *big.Int
+	xS     *big.Int
+}
+
+// U returns the scrambling parameter u.
+func (s *Session) U() *big.Int {
+	return s.u
+}
+
+// PremasterSecret returns the padded shared secret S.
+func (s *Session) PremasterSecret() []byte {
+	return s.params.Pad(s.xS)
+}
+
+// Key returns the session key K = H(S).
+func (s *Session) Key() []byte {
+	return s.params.H(s.PremasterSecret())
+}
@@ -0,0 +1,234 @@
+package srp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	_ "crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func fromHex(s string) *big.Int {
+	i, _ := big.NewInt(0).SetString(strings.Join(strings.Fields(s), ""), 16)
+	return i
+}
+
+// Test vectors from RFC 5054 Appendix B.
+func TestRFC5054(t *testing.T) {
+	params := RFC5054(Group1024, crypto.SHA1)
+	identity, password := "alice", "password123"
+	salt, _ := hex.DecodeString("BEB25379D1A8581EB5A727673A2441EE")
+
+	expectedK := fromHex("7556AA04 5AEF2CDD 07ABAF0F 665C3E81 8913186F")
+	expectedX := fromHex("94B7555A ABE9127C C58CCF49 93DB6CF8 4D16C124")
+	expectedV := fromHex(`
+		7E273DE8 696FFC4F 4E337D05 B4B375BE B0DDE156 9E8FA00A 9886D812 9BADA1F1 822223CA 1A605B53 0E379BA4 729FDC59
+		F105B478 7E5186F5 C671085A 1447B52A 48CF1970 B4FB6F84 00BBF4CE BFBB1681 52E08AB5 EA53D15C 1AFF87B2 B9DA6E04
+		E058AD51 CC72BFC9 033B564E 26480D78 E955A5E2 9E7AB245 DB2BE315 E2099AFB`)
+	a := fromHex("60975527 035CF2AD 1989806F 0407210B C81EDC04 E2762A56 AFD529DD DA2D4393")
+	b := fromHex("E487CB59 D31AC550 471E81F0 0F6928E0 1DDA08E9 74A004F4 9E61F5D1 05284D20")
+	expectedA := fromHex(`
+		61D5E490 F6F1B795 47B0704C 436F523D D0E560F0 C64115BB 72557EC4 4352E890 3211C046 92272D8B 2D1A5358 A2CF1B6E
+		0BFCF99F 921530EC 8E393561 79EAE45E 42BA92AE ACED8251 71E1E8B9 AF6D9C03 E1327F44 BE087EF0 6530E69F 66615261
+		EEF54073 CA11CF58 58F0EDFD FE15EFEA B349EF5D 76988A36 72FAC47B 0769447B`)
+	expectedB := fromHex(`
+		BD0C6151 2C692C0C B6D041FA 01BB152D 4916A1E7 7AF46AE1 05393011 BAF38964 DC46A067 0DD125B9 5A981652 236F99D9
+		B681CBF8 7837EC99 6C6DA044 53728610 D0C6DDB5 8B318885 D7D82C7F 8DEB75CE 7BD4FBAA 37089E6F 9C6059F3 88838E7A
+		00030B33 1EB76840 910440B1 B27AAEAE EB4012B7 D7665238 A8E3FB00 4B117B58`)
+	expectedU := fromHex("CE38B959 3487DA98 554ED47D 70A7AE5F 462EF019")
+	expectedS := fromHex(`
+		B0DC82BA BCF30674 AE450C02 87745E79 90A3381F 63B387AA F271A10D 233861E3 59B48220 F7C4693C 9AE12B0A 6F67809F
+		0876E2D0 13800D6C 41BB59B6 D5979B5C 00A172B4 A2A5903A 0BDCAF8A 709585EB 2AFAFA8F 3499B200 210DCC1F 10EB3394
+		3CD67FC8 8A2F39A4 BE5BEC4E C0A3212D C346D7E4 74B29EDE 8A469FFE CA686E5A`)
+
+	if k := params.K(); k.Cmp(expectedK) != 0 {
+		t.Errorf("k values not equal. Got: %x Expected: %x", k, expectedK)
+	}
+
+	if x := params.X(identity, password, salt); x.Cmp(expectedX) != 0 {
+		t.Errorf("x values not equal. Got: %x Expected: %x", x, expectedX)
+	}
+
+	v, err := Verifier(params, identity, password, salt)
+	if err != nil {
+		t.Fatalf("Verifier returned an error: %v", err)
+	}
+
+	if v.Cmp(expectedV) != 0 {
+		t.Errorf("v values not equal. Got: %x Expected: %x", v, expectedV)
+	}
+
+	client, err := NewClientWithKey(params, a)
+	if err != nil {
+		t.Fatalf("NewClientWithKey returned an error: %v", err)
+	}
+
+	if client.A().Cmp(expectedA) != 0 {
+		t.Errorf("A values not equal. Got: %x Expected: %x", client.A(), expectedA)
+	}
+
+	server, err := NewServerWithKey(params, v, b)
+	if err != nil {
+		t.Fatalf("NewServerWithKey returned an error: %v", err)
+	}
+
+	if server.B().Cmp(expectedB) != 0 {
+		t.Errorf("B values not equal. Got: %x Expected: %x", server.B(), expectedB)
+	}
+
+	clientSession, err := client.ComputeSecret(identity, password, salt, server.B())
+	if err != nil {
+		t.Fatalf("Client.ComputeSecret returned an error: %v", err)
+	}
+
+	serverSession, err := server.ComputeSecret(client.A())
+	if err != nil {
+		t.Fatalf("Server.ComputeSecret returned an error: %v", err)
+	}
+
+	for name, session := range map[string]*Session{"client": clientSession, "server": serverSession} {
+		if session.U().Cmp(expectedU) != 0 {
+			t.Errorf("%s: u values not equal. Got: %x Expected: %x", name, session.U(), expectedU)
+		}
+
+		if !bytes.Equal(session.PremasterSecret(), expectedS.Bytes()) {
+			t.Errorf("%s: premaster secrets not equal. Got: %x Expected: %x", name, session.PremasterSecret(), expectedS)
+		}
+	}
+}
+
+func TestClientServer(t *testing.T) {
+	paramsList := map[string]*Params{
+		"RFC5054-2048-SHA256": RFC5054(Group2048, crypto.SHA256),
+		"RFC5054-3072-SHA256": RFC5054(Group3072, crypto.SHA256),
+		"Cognito":             Cognito,
+	}
+
+	for name, params := range paramsList {
+		salt := []byte("salt")
+		v, err := Verifier(params, "user", "password", salt)
+		if err != nil {
+			t.Fatalf("%s: Verifier returned an error: %v", name, err)
+		}
+
+		client, err := NewClient(params, rand.Reader)
+		if err != nil {
+			t.Fatalf("%s: NewClient returned an error: %v", name, err)
+		}
+
+		server, err := NewServer(params, v, rand.Reader)
+		if err != nil {
+			t.Fatalf("%s: NewServer returned an error: %v", name, err)
+		}
+
+		clientSession, err := client.ComputeSecret("user", "password", salt, server.B())
+		if err != nil {
+			t.Fatalf("%s: Client.ComputeSecret returned an error: %v", name, err)
+		}
+
+		serverSession, err := server.ComputeSecret(client.A())
+		if err != nil {
+			t.Fatalf("%s: Server.ComputeSecret returned an error: %v", name, err)
+		}
+
+		if !bytes.Equal(clientSession.Key(), serverSession.Key()) {
+			t.Errorf("%s: keys not equal", name)
+		}
+
+		wrongSession, _ := client.ComputeSecret("user", "wrong", salt, server.B())
+		if bytes.Equal(wrongSession.Key(), serverSession.Key()) {
+			t.Errorf("%s: keys equal with wrong password", name)
+		}
+	}
+}
+
+// Test vector from a login against a Cognito user pool.
+func TestCognito(t *testing.T) {
+	a := fromHex("d77141184757d236e8e067054932c3335f59b24f44b27236274ea757929f709c51ab0095fcdf7d0a47519b1292a31e9e2f98d6510ecc70d4d40bfbc5d1fb02ea3e3dbac845702f29b69de7cb16adf13aa3866622fe4bacc15b9eab7dca1f6ff40f053eab95c31d7177418bf2b790a01403624d1923c509f902189fa31599a403334a4cdcf144f638dc46849862b17363cbc727c3e83da7024b543421f683da360aa9ccde8c7108c508cf417a69e92709da4eb58191236a620bf6057a29deaf71bc9b805376c567fe3907b971ed9dc1b159cb3bbfea490f52afbfef6e0b5f85d7bee569880cf95af39b3ebbc0f676647f9a7f2609ac21b2b26c1c22d21fdea95730ba7e7aa7765b1f5d51c77f0cbbd24bcce4c40161a96c7e4e6b3cf017f3b3f1c8d1c127ae111c422dd60b44cb3badfc84b70a2347a675b0fac3c8a954a502f778b58b5da76ae54ee0a4b6c963fefc5edda8ea5e866a28c57b4ecffbb0d5703c5a930721d02ed01e612816a58d75ecd88d29180393ab7ad25502fc6573fa4be4")
+	salt := fromHex("3541a780cb7ab335122f2fd7614fc96c")
+	xB := fromHex("b065ab5c4c4947f93dab03a7687601ec4f23cda3949c950886d3a555072fd62d8da9c73906e45ff944ad4163607f537c86ef90b5d387dd6c5d2a6c7031947bc8cc255e58358010ee7c617c64192639bbe5ae7de71a4b9494258783290ba6692b20b3864a460c041c9cca582b6a2135dc79038a0c43412b0d05350996f3efc7ccdd98e10bfa9c3b4c936f9076756d2971785003f17ed48e51ea1731470f9f6f8240a41fb81fb264e84bf62a63640390e637e046e176698b6b67e1dae6f571f80662346f5dfc09577848c0bba8f00335ba1a4c449a752566c8ed6855f21efbc567cc17e5761fae297ea288a3198068845a973587faaefe04b1ebb8091753d0385889e9cc9b7e8d4f6b50fc690c210dd54261706abd202eca32e14eb7e5c435bd3441ef0b8786641883b8273e96cbc0009025e31f4830610f0a322244bfb90133b9d886c7a6a96f494c16c9e624e56fece5e1920f832b59b88001e021b90c88e142f8c7aa40a7f2498477649afe3a9f54fdda79ae42a756d44cb880824a9e050e96")
+	secretBlock, _ := base64.StdEncoding.DecodeString("ut8rxqNX1ibqlO9/K0HdC2bW8VdLuvY3ejFZJhYqaj8ZUdgVmvOo/Uboc7hUhqr5J4X5tipOxaXOtpszRFbZzfs+P9CpAsETtJXp/R2YkYQlMtGro8z3WIuC3HUMxPBiZKQgc8K1HvCsCWJJl/GZzz/tyKvf61GPzbOk7fUmsCpGRFXh8SApLV4LD/bcADsZfF/lLvdM4G2anxfcVYFpJdqEhd4/hnC0jDbw+tRaftOiYu5fm7+HhniZad7VwxgDSD28aCyiQm7Nw4b4UvqwaRt/Zt5T/B0QEpvJseMC6x+96AzYdJ7YHbIV01GP1FZAoCS8RxVmGAYut8RR8N549q8jw0Bj/4fjAzOqQI9oTfBYAUqDLYUpFDsoj7RqaN0QEtO846XuLJM7tThUHwSCqwwDw6zTT/u/lL3apMPKkPvJjPRrHpGsW2H4PBI/FxPAgM6x/wQ36+u1/1OqcuQPK2fdPOiQr43uryTcJ8jWiuucr4PA8FcL8RIUx9U59/64fIz2jiVPywUExMK3+QCTRR/IZZO7Of4KWutFvh2EjIjdhDqhBqgwrJX4pmkVMXPHEcJiOwkFS+2WfOfj2lK5G1M5g0f/1QOffFzyfaA5/xv99Z5G/ldw+Jr1AtS9fkdzkCM89xYL/3bY2/5GH/hKywG/6U5xh1DjbaxaBX2ySI0HK6TN9XIw0Wts9S0eewHJ+0opvVwBhsY8Jo1K3vjlWqU1CYVqwB1OWbPyfdrkVVEiK+AinKWxflODz7IL3FBn1ZWRgN0PV/IxFV9jzqPbw/L+2WhvwhqgPC6twnEgQ4rn2F5DhmrJ61bhUEAmNfSkA9VJmTGCV702bMZT2QUldsdMC+U3S0yx22A/xIiuChOSabNlZdcHfQ/EhHydoA1KEA0X/vkvctM/nIF4x2+HOH2fiP2cyyX8BCd2J5dciWbEOE90L+rWWXCdJP2Juu684z85OMJ9XX6+k9jh5Llp/DwuOR+9MDhUdR7dpsRNqxfMn/KyMvBqQ7IiLyP8G1eCxR2AL3wFKPJbuc3/CpFg9P+j/ZuR/yRurVFKEDpVUwiblMQonxMO3CiK/6OvXZO0fv9+ECv2SpWSF9wF2EDQF4hbhNEQ+1Az8ZH6zlCNLBxxDyePXAwqKJKF389T/BdV8VK3gCU0Xz3qqcb8o/eLwDuKIX+hJ1eo/pmhPWnttqea75Ls/BMXfcw+eBdSOyIvrHEVM0Ach4SqO0o5O2cbyhf0VbFDYilwJ7kXy5rbkVa2jT4I3YYFI+m8HRwA8eC7dv184W585WtB81Snzd9rwOzYj1Sf5JgeO249kJCU/YIE81nvBRnrrXeNCQGFpbGJFNkvcUQ2htGYw7YvV2i/W70JW+mOJB09YEjD4Cxz6xXzTOGSsVeDWlpdcq7MGa9jU4FLBBBJY1PiUPvYURWwIF6xr1UfZHNU+Fxqjm39bVrDSK82UrDvuF2IWb9vRYDPUlI7PhoilLTjop2NRD7zpvVYLvaZ6OYcCDtkvSHl6NpD23EHnIRoMwU20qyyJRuWhrvXNl0PwK/DJmLR7APCU3cUJu7t3YSTf8MjRA34xKuFbf48aLku/MYb07aDm6PxaMAeI4/8jUqqWBqH3xEkKmVgvPzF/qTJH04H2/jA92oFjjgeBGF5ErPnw/5gKLYYVr+afXu6TlrqUuy0hoLuWFdd9sMWrvXaNYyeLj3ZqLuY9wITOJ6DNvQM")
+
+	if k := Cognito.K().Text(16); k != "538282c4354742d7cbbde2359fcf67f9f5b3a6b08791e5011b43b8a5b66d9ee6" {
+		t.Errorf("Unexpected k value: %s", k)
+	}
+
+	client, err := NewClientWithKey(Cognito, a)
+	if err != nil {
+		t.Fatalf("NewClientWithKey returned an error: %v", err)
+	}
+
+	session, err := client.ComputeSecret("yepGFqSiutestUser", "Password123!", CognitoSalt(salt), xB)
+	if err != nil {
+		t.Fatalf("ComputeSecret returned an error: %v", err)
+	}
+
+	signature := CognitoClaimSignature(CognitoKey(session), "yepGFqSiu", "testUser", secretBlock, "Tue Apr 16 08:43:29 UTC 2019")
+	if encoded := base64.StdEncoding.EncodeToString(signature); encoded != "P+2n9zEdbKkqbh11xRppYgGGns5tkjx3JZTFJxiADRo=" {
+		t.Errorf("Unexpected claim signature: %s", encoded)
+	}
+}
+
+func TestInvalidParameters(t *testing.T) {
+	params := RFC5054(Group1024, crypto.SHA1)
+	client, _ := NewClientWithKey(params, big.NewInt(1234))
+	server, _ := NewServerWithKey(params, big.NewInt(5678), big.NewInt(1234))
+
+	for _, xB := range []*big.Int{nil, big.NewInt(0), params.Group.N} {
+		if _, err := client.ComputeSecret("user", "password", nil, xB); err != ErrInvalidPublicKey {
+			t.Errorf("Expected ErrInvalidPublicKey for B: %v. Got: %v", xB, err)
+		}
+	}
+
+	for _, xA := range []*big.Int{nil, big.NewInt(0), params.Group.N, big.NewInt(0).Lsh(params.Group.N, 1)} {
+		if _, err := server.ComputeSecret(xA); err != ErrInvalidPublicKey {
+			t.Errorf("Expected ErrInvalidPublicKey for A: %v. Got: %v", xA, err)
+		}
+	}
+
+	for _, key := range []*big.Int{nil, big.NewInt(0), big.NewInt(-1), params.Group.N} {
+		if _, err := NewClientWithKey(params, key); err != ErrInvalidPrivateKey {
+			t.Errorf("Expected ErrInvalidPrivateKey for a: %v. Got: %v", key, err)
+		}
+	}
+
+	if _, err := NewClient(RFC5054(Group1024, crypto.MD4), rand.Reader); err != ErrHashUnavailable {
+		t.Errorf("Expected ErrHashUnavailable. Got: %v", err)
+	}
+}
+
+func TestParams_Pad_Signed(t *testing.T) {
+	tests := []struct {
+		in       int64
+		expected []byte
+	}{
+		{in: 0, expected: []byte{0x00}},
+		{in: 0x7f, expected: []byte{0x7f}},
+		{in: 0x80, expected: []byte{0x00, 0x80}},
+		{in: 0x0800, expected: []byte{0x08, 0x00}},
+		{in: 0xff00, expected: []byte{0x00, 0xff, 0x00}},
+	}
+
+	for _, test := range tests {
+		if res := Cognito.Pad(big.NewInt(test.in)); !bytes.Equal(res, test.expected) {
+			t.Errorf("Unexpected result for %x. Got: %x Expected: %x", test.in, res, test.expected)
+		}
+	}
+}
+
+func TestParams_Pad_ToGroup(t *testing.T) {
+	params := RFC5054(Group1024, crypto.SHA1)
+
+	res := params.Pad(big.NewInt(0x80))
+	if len(res) != 128 || res[127] != 0x80 || !bytes.Equal(res[:127], make([]byte, 127)) {
+		t.Errorf("Unexpected result: %x", res)
+	}
+
+	if res := params.Pad(params.Group.N); !bytes.Equal(res, params.Group.N.Bytes()) {
+		t.Errorf("Unexpected result for N: %x", res)
+	}
+}
@@ -3,40 +3,42 @@
 package integration
 
 import (
-	"fmt"
-	"github.com/aws/aws-sdk-go/aws"
 	"github.com/larwef/cognito/client"
+	"github.com/larwef/cognito/cognitotest"
+	"github.com/larwef/cognito/verifier"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
-// Test with a live endpoint that requires Cognito authentication
+// Test with an endpoint that requires Cognito authentication, backed by a local Cognito emulator.
 
 func TestCognito(t *testing.T) {
-	var userpoolID = ""
-	var clientID = ""
-	var username = ""
-	var password = ""
-	var region = "eu-west-1"
-	var testURL = ""
+	var username = "testUser"
+	var password = "Password123!"
 
 	// Setup
-	awsConf := &aws.Config{
-		Region: aws.String(region),
-	}
+	cognito := newCognito(t, cognitotest.User{Username: username, Password: password})
+	defer cognito.Close()
 
-	conf := &client.Config{
-		UserpoolID: userpoolID,
-		ClientID:   clientID,
-		Username:   username,
-		Password:   password,
-		AWSConfig:  awsConf,
-	}
+	jwtVerifier := &verifier.JWTVerifier{Issuer: cognito.Issuer()}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := jwtVerifier.Parse(r.Header.Get("Authorization"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(token.Claims["cognito:username"].(string)))
+	}))
+	defer api.Close()
+	var testURL = api.URL
+
+	conf := newConfig(cognito, username, password)
 
 	client, err := conf.Client()
 	if err != nil {
-		t.Errorf("error getting client: %v", err)
+		t.Fatalf("error getting client: %v", err)
 	}
 
 	req, err := http.NewRequest(http.MethodGet, testURL, nil)
@@ -46,7 +48,7 @@ func TestCognito(t *testing.T) {
 
 	res, err := client.Do(req)
 	if err != nil {
-		t.Errorf("error from %s: %v", req.Method, err)
+		t.Fatalf("error from %s: %v", req.Method, err)
 	}
 
 	if res.StatusCode < 200 || res.StatusCode > 299 {
@@ -58,5 +60,33 @@ func TestCognito(t *testing.T) {
 		t.Errorf("error getting payload: %v", err)
 	}
 
-	fmt.Println(string(payloadBytes))
+	if string(payloadBytes) != username {
+		t.Errorf("unexpected payload: %s", payloadBytes)
+	}
+}
+
+func newCognito(t *testing.T, users ...cognitotest.User) *cognitotest.Server {
+	pool, err := cognitotest.NewUserPool("", "")
+	if err != nil {
+		t.Fatalf("error creating user pool: %v", err)
+	}
+
+	for _, user := range users {
+		if err := pool.AddUser(user); err != nil {
+			t.Fatalf("error adding user: %v", err)
+		}
+	}
+
+	return cognitotest.NewServer(pool)
+}
+
+func newConfig(cognito *cognitotest.Server, username, password string) *client.Config {
+	return &client.Config{
+		UserpoolID:  cognito.UserPool().ID(),
+		ClientID:    cognito.UserPool().ClientID(),
+		Username:    username,
+		Password:    password,
+		Lightweight: true,
+		Endpoint:    cognito.URL,
+	}
 }
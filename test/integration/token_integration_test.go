@@ -4,7 +4,9 @@ package integration
 
 import (
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/larwef/cognito/client"
+	"github.com/larwef/cognito/cognitotest"
 	"testing"
 	"time"
 )
@@ -12,24 +14,27 @@ import (
 // Run integrationtest with a fresh Cognito user which needs FORCE_CHANGE_PASSWORD.
 
 func TestTokenSource(t *testing.T) {
-	var userpoolID = ""
-	var clientID = ""
-	var username = ""
-	var password = ""
+	var username = "testUser"
+	var password = "Password123!"
 	var region = "eu-west-1"
 
 	// Setup
+	cognito := newCognito(t, cognitotest.User{
+		Username: username,
+		Password: password,
+		Status:   "FORCE_CHANGE_PASSWORD",
+	})
+	defer cognito.Close()
+
 	awsConf := &aws.Config{
-		Region: aws.String(region),
+		Region:      aws.String(region),
+		Endpoint:    aws.String(cognito.URL),
+		Credentials: credentials.AnonymousCredentials,
 	}
 
-	conf := &client.Config{
-		UserpoolID: userpoolID,
-		ClientID:   clientID,
-		Username:   username,
-		Password:   password,
-		AWSConfig:  awsConf,
-	}
+	conf := newConfig(cognito, username, password)
+	conf.Lightweight = false
+	conf.AWSConfig = awsConf
 
 	tokenSource, err := client.NewTokenSource(conf)
 	if err != nil {
@@ -3,19 +3,34 @@
 package integration
 
 import (
+	"github.com/larwef/cognito/client"
+	"github.com/larwef/cognito/cognitotest"
 	"github.com/larwef/cognito/verifier"
 	"testing"
 )
 
 func TestJWTVerifier(t *testing.T) {
-	var issuer = ""
-	var testToken = ""
+	cognito := newCognito(t, cognitotest.User{Username: "testUser", Password: "Password123!"})
+	defer cognito.Close()
+
+	tokenSource, err := client.NewTokenSource(newConfig(cognito, "testUser", "Password123!"))
+	if err != nil {
+		t.Fatalf("Error getting TokenSource: %v", err)
+	}
+
+	token, err := tokenSource.GetToken()
+	if err != nil {
+		t.Fatalf("Error getting token: %v", err)
+	}
+
+	var issuer = cognito.Issuer()
+	var testToken = token.IDToken
 
 	jwtVerifier := verifier.JWTVerifier{
 		Issuer: issuer,
 	}
 
-	_, err := jwtVerifier.Parse(testToken)
+	_, err = jwtVerifier.Parse(testToken)
 	if err != nil {
 		t.Errorf("Parse returned an error: %v", err)
 	}
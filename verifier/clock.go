@@ -0,0 +1,17 @@
+package verifier
+
+import (
+	"time"
+
+	"github.com/larwef/cognito/internal/clock"
+)
+
+// Clock tells the current time. Set it on JWTVerifier to control expiry checks deterministically, eg. in tests.
+type Clock = clock.Clock
+
+// ClockFunc is an adapter to allow the use of ordinary functions as Clocks.
+type ClockFunc = clock.Func
+
+func (jv *JWTVerifier) now() time.Time {
+	return clock.Now(jv.Clock)
+}
@@ -0,0 +1,206 @@
+package verifier
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJWTVerifier_Parse_Clock(t *testing.T) {
+	now := time.Unix(1555709234, 0)
+	verifier := JWTVerifier{
+		Issuer: "https://cognito-idp.eu-west-1.amazonaws.com/eu-west-1_yepGFqSiu",
+		Clock:  ClockFunc(func() time.Time { return now }),
+		keys: map[string]key{
+			"yJgdps33v1Ng9NMiXICMxFAMC99h2p7TlXZAr09Yi+o=": {Kid: "yJgdps33v1Ng9NMiXICMxFAMC99h2p7TlXZAr09Yi+o=", pubKey: getPublicKey()},
+		},
+	}
+
+	if _, err := verifier.Parse(validSignatureTestToken); err != nil {
+		t.Errorf("Parse returned an error: %v", err)
+	}
+
+	// The token expires one second after now, and is accepted for DefaultLeeway after that.
+	now = now.Add(time.Second + DefaultLeeway)
+	if _, err := verifier.Parse(validSignatureTestToken); err != nil {
+		t.Errorf("Parse returned an error within the leeway: %v", err)
+	}
+
+	now = now.Add(time.Second)
+	if _, err := verifier.Parse(validSignatureTestToken); err != ErrTokenExpired {
+		t.Errorf("expected Parse to return error: %v but got: %v", ErrTokenExpired, err)
+	}
+}
+
+func TestJWTVerifier_Parse_NotBefore(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	nbf := time.Unix(1555705635, 0)
+	token := signTestToken(t, privateKey, fmt.Sprintf(`{"iss":"issuer","nbf":%d,"exp":%d}`, nbf.Unix(), nbf.Add(time.Hour).Unix()))
+
+	now := nbf.Add(-DefaultLeeway - time.Second)
+	verifier := JWTVerifier{
+		Issuer: "issuer",
+		Clock:  ClockFunc(func() time.Time { return now }),
+		keys:   map[string]key{"kid": {Kid: "kid", pubKey: &privateKey.PublicKey}},
+	}
+
+	if _, err := verifier.Parse(token); err != ErrTokenNotYetValid {
+		t.Errorf("expected Parse to return error: %v but got: %v", ErrTokenNotYetValid, err)
+	}
+
+	now = nbf.Add(-DefaultLeeway)
+	if _, err := verifier.Parse(token); err != nil {
+		t.Errorf("Parse returned an error: %v", err)
+	}
+}
+
+func signTestToken(t *testing.T, privateKey *rsa.PrivateKey, claims string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"kid":"kid","alg":"RS256"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+
+	digest := sha256.Sum256([]byte(header + "." + payload))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("error signing token: %v", err)
+	}
+
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTVerifier_Parse_IssuedAt(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	iat := time.Unix(1555705635, 0)
+	token := signTestToken(t, privateKey, fmt.Sprintf(`{"iss":"issuer","iat":%d,"exp":%d}`, iat.Unix(), iat.Add(time.Hour).Unix()))
+
+	now := iat.Add(-DefaultLeeway - time.Second)
+	verifier := JWTVerifier{
+		Issuer: "issuer",
+		Clock:  ClockFunc(func() time.Time { return now }),
+		keys:   map[string]key{"kid": {Kid: "kid", pubKey: &privateKey.PublicKey}},
+	}
+
+	if _, err := verifier.Parse(token); err != ErrTokenUsedBeforeIssued {
+		t.Errorf("expected Parse to return error: %v but got: %v", ErrTokenUsedBeforeIssued, err)
+	}
+
+	now = iat.Add(-DefaultLeeway)
+	if _, err := verifier.Parse(token); err != nil {
+		t.Errorf("Parse returned an error: %v", err)
+	}
+}
+
+func TestJWTVerifier_Parse_Leeway(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+
+	nbf := time.Unix(1555705635, 0)
+	token := signTestToken(t, privateKey, fmt.Sprintf(`{"iss":"issuer","nbf":%d,"exp":%d}`, nbf.Unix(), nbf.Add(time.Hour).Unix()))
+
+	now := nbf.Add(-5 * time.Second)
+	verifier := JWTVerifier{
+		Issuer: "issuer",
+		Clock:  ClockFunc(func() time.Time { return now }),
+		Leeway: 5 * time.Second,
+		keys:   map[string]key{"kid": {Kid: "kid", pubKey: &privateKey.PublicKey}},
+	}
+
+	if _, err := verifier.Parse(token); err != nil {
+		t.Errorf("Parse returned an error: %v", err)
+	}
+
+	// A negative Leeway allows no skew.
+	verifier.Leeway = -1
+	now = nbf.Add(-time.Second)
+	if _, err := verifier.Parse(token); err != ErrTokenNotYetValid {
+		t.Errorf("expected Parse to return error: %v but got: %v", ErrTokenNotYetValid, err)
+	}
+
+	now = nbf.Add(time.Hour + time.Second)
+	if _, err := verifier.Parse(token); err != ErrTokenExpired {
+		t.Errorf("expected Parse to return error: %v but got: %v", ErrTokenExpired, err)
+	}
+}
+
+func TestJWTVerifier_KeysTTL(t *testing.T) {
+	var fetches int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		http.ServeFile(w, r, "../test/testdata/jwks.json")
+	}))
+	defer server.Close()
+
+	now := time.Unix(1555709234, 0)
+	verifier := JWTVerifier{
+		Issuer:  server.URL,
+		Clock:   ClockFunc(func() time.Time { return now }),
+		KeysTTL: time.Minute,
+	}
+
+	// The token is rejected for its issuer after the keys are fetched.
+	parse := func() {
+		if _, err := verifier.Parse(validSignatureTestToken); err != ErrIssuerDoesntMatch {
+			t.Errorf("expected Parse to return error: %v but got: %v", ErrIssuerDoesntMatch, err)
+		}
+	}
+
+	parse()
+	now = now.Add(59 * time.Second)
+	parse()
+	if fetches != 1 {
+		t.Errorf("Unexpected number of fetches: %d. Expected: %d", fetches, 1)
+	}
+
+	now = now.Add(time.Second)
+	parse()
+	if fetches != 2 {
+		t.Errorf("Unexpected number of fetches: %d. Expected: %d", fetches, 2)
+	}
+}
+
+func TestJWTVerifier_Parse_Concurrent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "../test/testdata/jwks.json")
+	}))
+	defer server.Close()
+
+	now := time.Unix(1555709234, 0)
+	verifier := JWTVerifier{
+		Issuer:          server.URL,
+		SkipIssuerCheck: true,
+		Clock:           ClockFunc(func() time.Time { return now }),
+		// The keys are fetched again on every Parse.
+		KeysTTL: time.Nanosecond,
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, err := verifier.Parse(validSignatureTestToken); err != nil {
+					t.Errorf("Parse returned an error: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,12 @@
+package verifier
+
+import "github.com/larwef/cognito/internal/logging"
+
+// Logger is a structured logger taking a message followed by alternating keys and values. It is implemented by
+// *slog.Logger. Values of keys like "password" or "token", and values holding JWTs, are always redacted before they
+// reach the Logger.
+type Logger = logging.Logger
+
+func (jv *JWTVerifier) logger() Logger {
+	return logging.New(jv.Logger)
+}
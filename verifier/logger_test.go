@@ -0,0 +1,46 @@
+package verifier
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type recordingLogger struct {
+	records []string
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.record("DEBUG", msg, args) }
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.record("INFO", msg, args) }
+func (l *recordingLogger) Warn(msg string, args ...interface{})  { l.record("WARN", msg, args) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.record("ERROR", msg, args) }
+
+func (l *recordingLogger) record(level, msg string, args []interface{}) {
+	l.records = append(l.records, fmt.Sprintf("%s %s %v", level, msg, args))
+}
+
+func TestJWTVerifier_Logger(t *testing.T) {
+	serverURL, teardown := issuerMock()
+	defer teardown()
+
+	logger := &recordingLogger{}
+	verifier := JWTVerifier{
+		Issuer: serverURL,
+		Logger: logger,
+	}
+
+	if _, err := verifier.Parse(validSignatureTestToken); err != ErrIssuerDoesntMatch {
+		t.Errorf("expected Parse to return error: %v but got: %v", ErrIssuerDoesntMatch, err)
+	}
+
+	out := strings.Join(logger.records, "\n")
+	for _, expected := range []string{"fetching JWKS", "fetched JWKS", "yJgdps33v1Ng9NMiXICMxFAMC99h2p7TlXZAr09Yi+o=", "WARN rejected token", ErrIssuerDoesntMatch.Error()} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected log to contain %q. Got:\n%s", expected, out)
+		}
+	}
+
+	if strings.Contains(out, validSignatureTestToken[:20]) {
+		t.Errorf("Log contains token:\n%s", out)
+	}
+}
@@ -67,12 +67,15 @@ func ParseJWT(token string) (*JWTToken, error) {
 	}, nil
 }
 
-// GetIssuer returns the issuer(iss) attribute from the jwt token.
+// GetIssuer returns the issuer(iss) attribute from the jwt token, or an empty string if it is missing.
 func (jt *JWTToken) GetIssuer() string {
-	return jt.Claims["iss"].(string)
+	iss, _ := jt.Claims["iss"].(string)
+	return iss
 }
 
-// GetExpiration returns the expiration(exp) attribute from the jwt token.
+// GetExpiration returns the expiration(exp) attribute from the jwt token. A missing exp is returned as the Unix epoch,
+// so the token is treated as expired.
 func (jt *JWTToken) GetExpiration() time.Time {
-	return time.Unix(int64(jt.Claims["exp"].(float64)), 0)
+	exp, _ := jt.Claims["exp"].(float64)
+	return time.Unix(int64(exp), 0)
 }
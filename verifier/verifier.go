@@ -11,6 +11,7 @@ import (
 	"math/big"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -30,6 +31,12 @@ var (
 	// ErrUnexpectedAlg is returned when a token with unsupported signing method is received.
 	ErrUnexpectedAlg = errors.New("unexpected signing algorithm. Currently only RS256 is supported")
 
+	// ErrTokenNotYetValid is returned when the token has a not before(nbf) time in the future.
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+
+	// ErrTokenUsedBeforeIssued is returned when the token has an issued at(iat) time in the future.
+	ErrTokenUsedBeforeIssued = errors.New("token used before issued")
+
 	// ErrNoPublicKeysFound is returned if the response from the keys endpoint is empty for any reason.
 	ErrNoPublicKeysFound = errors.New("found no public keys")
 )
@@ -48,23 +55,59 @@ type keyResp struct {
 	Keys []key `json:"keys"`
 }
 
-// JWTVerifier is used to parse and verify JWT tokens from a specific Issuer.
+const (
+	// DefaultKeysTTL is how long public keys fetched from the issuer are used if JWTVerifier.KeysTTL is not set.
+	DefaultKeysTTL = time.Hour
+
+	// DefaultLeeway is the clock skew allowed if JWTVerifier.Leeway is not set.
+	DefaultLeeway = 30 * time.Second
+)
+
+// JWTVerifier is used to parse and verify JWT tokens from a specific Issuer. It is safe for concurrent use, but the
+// fields must not be changed once Parse has been called.
 type JWTVerifier struct {
 	Client *http.Client
 	Issuer string
-	keys   map[string]key
+	// SkipIssuerCheck accepts tokens from any issuer. The public keys are still fetched from Issuer, so it is only
+	// useful with a Client serving the keys of a known issuer, eg. from a local file.
+	SkipIssuerCheck bool
+	// Logger receives log records about JWKS fetches and rejected tokens, eg. a *slog.Logger. Token values are never
+	// logged.
+	Logger Logger
+	// Clock is used to tell the time when checking the expiration, not before and issued at times of tokens, and when
+	// the public keys expire. If nil, the system clock is used.
+	Clock Clock
+	// KeysTTL is how long the public keys are used before they are fetched from the issuer again, so rotated keys are
+	// picked up. If 0, DefaultKeysTTL is used.
+	KeysTTL time.Duration
+	// Leeway is the clock skew allowed between the issuer and the verifier when checking the expiration, not before
+	// and issued at times of tokens. If 0, DefaultLeeway is used. A negative value allows no skew.
+	Leeway time.Duration
+
+	// mu guards Client, keys and keysExpiry, which are set when the public keys are fetched.
+	mu         sync.RWMutex
+	keys       map[string]key
+	keysExpiry time.Time
 }
 
 // Parse parses a token string and returns the parsed token if valid.
 func (jv *JWTVerifier) Parse(token string) (*JWTToken, error) {
-	return jv.parse(token, time.Now())
+	return jv.parse(token, jv.now())
 }
 
 func (jv *JWTVerifier) parse(token string, timeStamp time.Time) (*JWTToken, error) {
-	if len(jv.keys) <= 0 {
-		if err := jv.getPublicKeys(); err != nil {
-			return nil, err
-		}
+	jwtToken, err := jv.verify(token, timeStamp)
+	if err != nil {
+		jv.logger().Warn("rejected token", "reason", err)
+		return nil, err
+	}
+
+	return jwtToken, nil
+}
+
+func (jv *JWTVerifier) verify(token string, timeStamp time.Time) (*JWTToken, error) {
+	if err := jv.loadPublicKeys(); err != nil {
+		return nil, err
 	}
 
 	jwtToken, err := ParseJWT(token)
@@ -72,16 +115,26 @@ func (jv *JWTVerifier) parse(token string, timeStamp time.Time) (*JWTToken, erro
 		return nil, fmt.Errorf("error parsing jwt token: %v", err)
 	}
 
-	if jwtToken.GetIssuer() != jv.Issuer {
+	// The signature is verified first, so the claims of a forged token are never trusted.
+	if err := jv.verifySignature(jwtToken); err != nil {
+		return nil, err
+	}
+
+	if !jv.SkipIssuerCheck && jwtToken.GetIssuer() != jv.Issuer {
 		return nil, ErrIssuerDoesntMatch
 	}
 
-	if timeStamp.After(jwtToken.GetExpiration()) {
+	leeway := jv.leeway()
+	if timeStamp.Add(-leeway).After(jwtToken.GetExpiration()) {
 		return nil, ErrTokenExpired
 	}
 
-	if err := jv.verifySignature(jwtToken); err != nil {
-		return nil, err
+	if nbf, ok := jwtToken.Claims["nbf"].(float64); ok && timeStamp.Add(leeway).Before(time.Unix(int64(nbf), 0)) {
+		return nil, ErrTokenNotYetValid
+	}
+
+	if iat, ok := jwtToken.Claims["iat"].(float64); ok && timeStamp.Add(leeway).Before(time.Unix(int64(iat), 0)) {
+		return nil, ErrTokenUsedBeforeIssued
 	}
 
 	return jwtToken, nil
@@ -92,8 +145,11 @@ func (jv *JWTVerifier) verifySignature(token *JWTToken) error {
 		return ErrUnexpectedAlg
 	}
 
+	jv.mu.RLock()
 	key, exists := jv.keys[token.Header.Kid]
+	jv.mu.RUnlock()
 	if !exists {
+		jv.logger().Debug("unknown key id", "kid", token.Header.Kid)
 		return ErrMissingPublicKey
 	}
 
@@ -110,16 +166,34 @@ func (jv *JWTVerifier) verifySignature(token *JWTToken) error {
 	return nil
 }
 
+// loadPublicKeys fetches the public keys if there are none or they have expired.
+func (jv *JWTVerifier) loadPublicKeys() error {
+	jv.mu.RLock()
+	loaded := jv.keysLoaded()
+	jv.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	jv.mu.Lock()
+	defer jv.mu.Unlock()
+	// Another goroutine may have fetched the keys while waiting for the lock.
+	if jv.keysLoaded() {
+		return nil
+	}
+
+	return jv.getPublicKeys()
+}
+
+// getPublicKeys fetches the public keys from the issuer. Must be called with mu held.
 func (jv *JWTVerifier) getPublicKeys() error {
 	if jv.Client == nil {
 		jv.Client = http.DefaultClient
 	}
 
-	if jv.keys == nil {
-		jv.keys = make(map[string]key)
-	}
-
+	keys := make(map[string]key)
 	keysURL := jv.Issuer + "/.well-known/jwks.json"
+	jv.logger().Debug("fetching JWKS", "url", keysURL)
 	res, err := jv.Client.Get(keysURL)
 	if err != nil {
 		return fmt.Errorf("unable to get keysRes from %s. Error: %v", keysURL, err)
@@ -155,13 +229,42 @@ func (jv *JWTVerifier) getPublicKeys() error {
 				N: big.NewInt(0).SetBytes(nBytes),
 				E: int(big.NewInt(0).SetBytes(eBytes).Int64()),
 			}
-			jv.keys[key.Kid] = key
+			keys[key.Kid] = key
+			jv.logger().Debug("loaded public key", "kid", key.Kid)
 		}
 	}
 
+	jv.keys = keys
+	jv.keysExpiry = jv.now().Add(jv.keysTTL())
+	jv.logger().Info("fetched JWKS", "url", keysURL, "keys", len(jv.keys))
 	return nil
 }
 
+// keysLoaded reports whether there are public keys which were fetched less than KeysTTL ago. Must be called with mu
+// held.
+func (jv *JWTVerifier) keysLoaded() bool {
+	return len(jv.keys) > 0 && (jv.keysExpiry.IsZero() || jv.now().Before(jv.keysExpiry))
+}
+
+func (jv *JWTVerifier) keysTTL() time.Duration {
+	if jv.KeysTTL > 0 {
+		return jv.KeysTTL
+	}
+
+	return DefaultKeysTTL
+}
+
+func (jv *JWTVerifier) leeway() time.Duration {
+	if jv.Leeway < 0 {
+		return 0
+	}
+	if jv.Leeway > 0 {
+		return jv.Leeway
+	}
+
+	return DefaultLeeway
+}
+
 // Pads before decoding if necessary
 func decodeWithPadding(encoded string) ([]byte, error) {
 	if l := len(encoded) % 4; l > 0 {
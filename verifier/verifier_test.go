@@ -131,7 +131,7 @@ func TestJWTVerifier_Parse_TokenExpired(t *testing.T) {
 		pubKey: getPublicKey(),
 	}
 
-	_, err := verifier.parse(validSignatureTestToken, time.Unix(1555709236, 0))
+	_, err := verifier.parse(validSignatureTestToken, time.Unix(1555709236, 0).Add(DefaultLeeway))
 	if err != ErrTokenExpired {
 		t.Errorf("expected Parse to return error: %v but got: %v", ErrTokenExpired, err)
 	}
@@ -157,6 +157,27 @@ func TestJWTVerifier_Parse_InvalidSignature(t *testing.T) {
 	if err != ErrInvalidSignature {
 		t.Errorf("expected Parse to return error: %v but got: %v", ErrInvalidSignature, err)
 	}
+
+	// The signature is checked before the issuer and expiry.
+	verifier.Issuer = "SomeOtherIssuer"
+	_, err = verifier.parse(invalidSignatureTestToken, time.Unix(1555709389, 0))
+	if err != ErrInvalidSignature {
+		t.Errorf("expected Parse to return error: %v but got: %v", ErrInvalidSignature, err)
+	}
+}
+
+func TestJWTVerifier_Parse_SkipIssuerCheck(t *testing.T) {
+	verifier := JWTVerifier{
+		Issuer:          "SomeOtherIssuer",
+		SkipIssuerCheck: true,
+		keys: map[string]key{
+			"yJgdps33v1Ng9NMiXICMxFAMC99h2p7TlXZAr09Yi+o=": {Kid: "yJgdps33v1Ng9NMiXICMxFAMC99h2p7TlXZAr09Yi+o=", pubKey: getPublicKey()},
+		},
+	}
+
+	if _, err := verifier.parse(validSignatureTestToken, time.Unix(1555709234, 0)); err != nil {
+		t.Errorf("parse returned an error: %v", err)
+	}
 }
 
 func TestJWTVerifier_Parse_MissingPublicKey(t *testing.T) {